@@ -0,0 +1,298 @@
+// Command md2slack converts markdown to Slack Block Kit.
+//
+// Usage:
+//
+//	md2slack [flags] [file ...]
+//	md2slack preview [flags] [file]
+//
+// It reads the given files, or standard input if there are none or a file is "-",
+// and writes the blocks in the format chosen with -format. With -validate it exits
+// with status 1 if the blocks violate Block Kit limits, and with -post it posts them
+// to an incoming webhook or, with a bot token, to a channel instead of writing them.
+//
+// The preview subcommand serves a page to edit markdown on with a live preview of
+// its blocks, see runPreview.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/slack-go/slack"
+	slackUtil "github.com/takara2314/slack-go-util"
+	"github.com/yuin/goldmark/extension"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv)
+	stop()
+	os.Exit(code)
+}
+
+type options struct {
+	format     string
+	heading    string
+	lineBreaks string
+	gfm        bool
+	emoji      bool
+	maxBlocks  int
+	width      int
+	color      bool
+	validate   bool
+	post       bool
+	webhookURL string
+	token      string
+	channel    string
+	overflow   string
+	apiURL     string
+}
+
+// run runs the command and returns its exit status: 0 on success, 1 on failure
+// and 2 on invalid usage.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	if len(args) > 0 && args[0] == "preview" {
+		return runPreview(ctx, args[1:], stderr)
+	}
+
+	var opts options
+	flags := flag.NewFlagSet("md2slack", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: md2slack [flags] [file ...]")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&opts.format, "format", "blocks", "output `format`: blocks, payload, builder, html or text")
+	converterFlags(flags, &opts)
+	flags.IntVar(&opts.maxBlocks, "max-blocks", slackUtil.MaxMessageBlocks, "maximum `number` of blocks per posted message, and of the blocks checked by -validate")
+	flags.IntVar(&opts.width, "width", 80, "`columns` to wrap text output at, or 0 not to wrap")
+	flags.BoolVar(&opts.color, "color", false, "use ANSI styles in text output")
+	flags.BoolVar(&opts.validate, "validate", false, "exit with status 1 if the blocks violate Block Kit limits")
+	flags.BoolVar(&opts.post, "post", false, "post the blocks instead of writing them")
+	flags.StringVar(&opts.webhookURL, "webhook", "", "incoming webhook `URL` to post to (default $SLACK_WEBHOOK_URL)")
+	flags.StringVar(&opts.token, "token", "", "bot `token` to post with (default $SLACK_BOT_TOKEN)")
+	flags.StringVar(&opts.channel, "channel", "", "channel `ID` to post to with a bot token")
+	flags.StringVar(&opts.overflow, "overflow", "thread", "how to post the rest of long documents with a bot token: `thread` replies or follow-up messages")
+	flags.StringVar(&opts.apiURL, "api-url", slack.APIURL, "Slack Web API base `URL`")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	// The secrets are not flag defaults, which usage messages would print
+	if opts.webhookURL == "" {
+		opts.webhookURL = getenv("SLACK_WEBHOOK_URL")
+	}
+	if opts.token == "" {
+		opts.token = getenv("SLACK_BOT_TOKEN")
+	}
+
+	converter, err := newConverter(opts)
+	if err == nil && opts.post {
+		err = checkPostOptions(opts)
+	} else if err == nil && !opts.post {
+		err = checkFormat(opts.format)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+		return 2
+	}
+
+	markdown, err := readInput(flags.Args(), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+		return 1
+	}
+
+	if err := convert(ctx, converter, opts, markdown, stdout, stderr); err != nil {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// converterFlags defines the flags of the converter options.
+func converterFlags(flags *flag.FlagSet, opts *options) {
+	flags.StringVar(&opts.heading, "heading", "header", "heading `style`: header or bold")
+	flags.StringVar(&opts.lineBreaks, "line-breaks", "newline", "soft line break `mode`: newline or space")
+	flags.BoolVar(&opts.gfm, "gfm", true, "enable GitHub Flavored Markdown tables, strikethrough, task lists and autolinks")
+	flags.BoolVar(&opts.emoji, "emoji", true, "render emoji shortcodes in plain text such as headers")
+}
+
+func newConverter(opts options) (*slackUtil.Converter, error) {
+	converterOpts := []slackUtil.Option{
+		slackUtil.WithEmoji(opts.emoji),
+		slackUtil.WithBlocksPerMessage(opts.maxBlocks),
+	}
+	if opts.gfm {
+		converterOpts = append(converterOpts, slackUtil.WithExtensions(extension.GFM))
+	}
+
+	switch opts.heading {
+	case "header":
+		converterOpts = append(converterOpts, slackUtil.WithHeadingStyle(slackUtil.HeadingStyleHeader))
+	case "bold":
+		converterOpts = append(converterOpts, slackUtil.WithHeadingStyle(slackUtil.HeadingStyleBold))
+	default:
+		return nil, fmt.Errorf("unknown heading style %q", opts.heading)
+	}
+
+	switch opts.lineBreaks {
+	case "newline":
+		converterOpts = append(converterOpts, slackUtil.WithLineBreaks(slackUtil.LineBreakNewline))
+	case "space":
+		converterOpts = append(converterOpts, slackUtil.WithLineBreaks(slackUtil.LineBreakSpace))
+	default:
+		return nil, fmt.Errorf("unknown line break mode %q", opts.lineBreaks)
+	}
+
+	return slackUtil.NewConverter(converterOpts...), nil
+}
+
+func checkFormat(format string) error {
+	switch format {
+	case "blocks", "payload", "builder", "html", "text":
+		return nil
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func checkPostOptions(opts options) error {
+	switch {
+	case opts.webhookURL != "":
+		return nil
+	case opts.token == "":
+		return errors.New("-post needs -webhook or -token")
+	case opts.channel == "":
+		return errors.New("-post with a bot token needs -channel")
+	case opts.overflow != "thread" && opts.overflow != "messages":
+		return fmt.Errorf("unknown overflow mode %q", opts.overflow)
+	}
+	return nil
+}
+
+// readInput reads and joins the files, or standard input if there are none.
+func readInput(files []string, stdin io.Reader) (string, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	parts := make([]string, 0, len(files))
+	for _, file := range files {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, strings.TrimRight(string(data), "\n"))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+func convert(ctx context.Context, converter *slackUtil.Converter, opts options, markdown string, stdout, stderr io.Writer) error {
+	if opts.post {
+		chunks, err := converter.ConvertMarkdownTextToBlockChunks(markdown)
+		if err != nil {
+			return err
+		}
+		if opts.validate {
+			var errs []slackUtil.ValidationError
+			for _, chunk := range chunks {
+				errs = append(errs, slackUtil.ValidateBlocks(chunk, slackUtil.ValidateMaxBlocks(opts.maxBlocks))...)
+			}
+			if err := reportValidation(errs, stderr); err != nil {
+				return err
+			}
+		}
+		return post(ctx, converter, opts, markdown, stdout)
+	}
+
+	blocks, err := converter.ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		return err
+	}
+	if opts.validate {
+		if err := reportValidation(slackUtil.ValidateBlocks(blocks, slackUtil.ValidateMaxBlocks(opts.maxBlocks)), stderr); err != nil {
+			return err
+		}
+	}
+	if blocks == nil {
+		blocks = []slack.Block{}
+	}
+
+	switch opts.format {
+	case "blocks":
+		return writeJSON(stdout, blocks)
+	case "payload":
+		return writeJSON(stdout, slackUtil.Payload{Text: converter.ConvertMarkdownTextToFallbackText(markdown), Blocks: blocks})
+	case "builder":
+		u, err := slackUtil.BlockKitBuilderURL(blocks)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, u)
+		return err
+	case "html":
+		_, err := io.WriteString(stdout, slackUtil.RenderBlocksToHTML(blocks))
+		return err
+	default:
+		text := slackUtil.RenderBlocksToTerminal(blocks, slackUtil.TerminalWidth(opts.width), slackUtil.TerminalColor(opts.color))
+		_, err := fmt.Fprintln(stdout, text)
+		return err
+	}
+}
+
+func reportValidation(errs []slackUtil.ValidationError, stderr io.Writer) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, err := range errs {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+	}
+	return fmt.Errorf("%d Block Kit violations", len(errs))
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// post posts the markdown with a bot token, or to the incoming webhook.
+func post(ctx context.Context, converter *slackUtil.Converter, opts options, markdown string, stdout io.Writer) error {
+	if opts.webhookURL != "" {
+		msgs, err := converter.ConvertMarkdownTextToWebhookMessages(markdown)
+		if err != nil {
+			return err
+		}
+		return slackUtil.SendWebhookMessages(ctx, opts.webhookURL, msgs)
+	}
+
+	apiURL := opts.apiURL
+	if !strings.HasSuffix(apiURL, "/") {
+		apiURL += "/"
+	}
+	client := slack.New(opts.token, slack.OptionAPIURL(apiURL))
+
+	overflow := slackUtil.OverflowThread
+	if opts.overflow == "messages" {
+		overflow = slackUtil.OverflowMessages
+	}
+	timestamps, err := converter.PostMarkdown(ctx, client, opts.channel, markdown, slackUtil.PostOverflow(overflow))
+	for _, ts := range timestamps {
+		fmt.Fprintf(stdout, "%s %s\n", opts.channel, ts)
+	}
+	return err
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runCommand(t *testing.T, stdin string, env map[string]string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	getenv := func(key string) string { return env[key] }
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr, getenv)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "blocks",
+			args: nil,
+			want: []string{`"type": "header"`, `"text": "Title"`, `"type": "rich_text"`},
+		},
+		{
+			name: "payload",
+			args: []string{"-format", "payload"},
+			want: []string{`"text": "Title`, `"blocks": [`},
+		},
+		{
+			name: "builder",
+			args: []string{"-format", "builder"},
+			want: []string{"https://app.slack.com/block-kit-builder/#%7B%22blocks%22:%5B"},
+		},
+		{
+			name: "html",
+			args: []string{"-format", "html"},
+			want: []string{"<!DOCTYPE html>", `<h1 class="block header">Title</h1>`},
+		},
+		{
+			name: "text",
+			args: []string{"-format", "text"},
+			want: []string{"Title\n\nSome bold text\n\n• one\n"},
+		},
+		{
+			name: "bold headings",
+			args: []string{"-heading", "bold"},
+			want: []string{`"text": "*Title*"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(t, "# Title\n\nSome **bold** text\n\n- one\n", nil, tt.args...)
+			if code != 0 {
+				t.Fatalf("run() = %d, stderr: %s", code, stderr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("output does not contain %q:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.md")
+	second := filepath.Join(dir, "second.md")
+	if err := os.WriteFile(first, []byte("first\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("second\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCommand(t, "stdin", nil, "-format", "text", first, "-", second)
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr)
+	}
+	if want := "first\n\nstdin\n\nsecond\n"; stdout != want {
+		t.Errorf("output = %q, want %q", stdout, want)
+	}
+
+	if code, _, _ := runCommand(t, "", nil, filepath.Join(dir, "missing.md")); code != 1 {
+		t.Errorf("run() with a missing file = %d, want 1", code)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"-format", "pdf"},
+		{"-heading", "huge"},
+		{"-line-breaks", "none"},
+		{"-post"},
+		{"-post", "-token", "xoxb-1"},
+		{"-post", "-token", "xoxb-1", "-channel", "C1", "-overflow", "dm"},
+		{"-unknown"},
+	} {
+		if code, _, _ := runCommand(t, "text", nil, args...); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunUsageHidesSecrets(t *testing.T) {
+	env := map[string]string{"SLACK_WEBHOOK_URL": "https://hooks.slack.com/services/secret", "SLACK_BOT_TOKEN": "xoxb-secret"}
+	for _, args := range [][]string{{"-h"}, {"-unknown"}} {
+		_, _, stderr := runCommand(t, "text", env, args...)
+		if !strings.Contains(stderr, "(default $SLACK_BOT_TOKEN)") {
+			t.Errorf("run(%q) usage = %q", args, stderr)
+		}
+		if strings.Contains(stderr, "secret") {
+			t.Errorf("run(%q) usage prints a secret: %q", args, stderr)
+		}
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	code, stdout, stderr := runCommand(t, "# Title\n\ntext", nil, "-validate")
+	if code != 0 || stdout == "" {
+		t.Errorf("run() = %d, stderr: %s", code, stderr)
+	}
+
+	// each table is a block of its own
+	markdown := strings.Repeat("| a |\n| - |\n| 1 |\n\n", 60)
+	code, stdout, stderr = runCommand(t, markdown, nil, "-validate")
+	if code != 1 {
+		t.Errorf("run() = %d, want 1", code)
+	}
+	if stdout != "" {
+		t.Errorf("output = %q, want none", stdout)
+	}
+	if !strings.Contains(stderr, "blocks: must not contain more than 50 blocks, got 60") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	// a modal or home tab holds more blocks than a message
+	code, _, stderr = runCommand(t, strings.Repeat("para\n\n", 60), nil, "-validate", "-max-blocks", "100")
+	if code != 0 {
+		t.Errorf("run() = %d, stderr: %s", code, stderr)
+	}
+}
+
+func TestRunPostWebhook(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		bodies = append(bodies, body)
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	code, _, stderr := runCommand(t, "# Title\n\ntext", map[string]string{"SLACK_WEBHOOK_URL": server.URL}, "-post")
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("got %d webhook requests, want 1", len(bodies))
+	}
+	if text := bodies[0]["text"]; text != "Title\ntext" {
+		t.Errorf("text = %q", text)
+	}
+	if blocks, _ := bodies[0]["blocks"].([]any); len(blocks) != 2 {
+		t.Errorf("blocks = %v", bodies[0]["blocks"])
+	}
+}
+
+func TestRunPostToken(t *testing.T) {
+	var forms []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat.postMessage" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if got := r.PostForm.Get("token"); got != "xoxb-1" && r.Header.Get("Authorization") != "Bearer xoxb-1" {
+			t.Errorf("token = %q", got)
+		}
+		forms = append(forms, r.PostForm)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"ok": true, "channel": "C1", "ts": "1700000000.00000`+string(rune('0'+len(forms)))+`"}`)
+	}))
+	defer server.Close()
+
+	markdown := "one\n\n---\n\ntwo\n\n---\n\nthree"
+	code, stdout, stderr := runCommand(t, markdown, nil,
+		"-post", "-token", "xoxb-1", "-channel", "C1", "-api-url", server.URL, "-max-blocks", "2")
+	if code != 0 {
+		t.Fatalf("run() = %d, stderr: %s", code, stderr)
+	}
+	if len(forms) != 3 {
+		t.Fatalf("got %d messages, want 3", len(forms))
+	}
+	if want := "C1 1700000000.000001\nC1 1700000000.000002\nC1 1700000000.000003\n"; stdout != want {
+		t.Errorf("output = %q, want %q", stdout, want)
+	}
+	if got := forms[1].Get("text"); got != "--- two" {
+		t.Errorf("text = %q", got)
+	}
+	if got := forms[2].Get("thread_ts"); got != "1700000000.000001" {
+		t.Errorf("thread_ts = %q", got)
+	}
+	if got := forms[0].Get("channel"); got != "C1" {
+		t.Errorf("channel = %q", got)
+	}
+
+	server.Close()
+	if code, _, _ := runCommand(t, markdown, nil, "-post", "-token", "xoxb-1", "-channel", "C1", "-api-url", server.URL); code != 1 {
+		t.Errorf("run() with an unreachable API = %d, want 1", code)
+	}
+}
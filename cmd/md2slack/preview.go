@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/slack-go/slack"
+	slackUtil "github.com/takara2314/slack-go-util"
+)
+
+// maxPreviewInputSize limits the markdown accepted by the convert endpoint.
+const maxPreviewInputSize = 1 << 20
+
+// runPreview serves a page with a markdown editor, the rendered blocks and their
+// JSON, which are updated on every keystroke. With a file, the editor is loaded
+// from it and reloaded whenever it changes on disk. Nothing is sent to Slack.
+func runPreview(ctx context.Context, args []string, stderr io.Writer) int {
+	var opts options
+	var addr string
+	flags := flag.NewFlagSet("md2slack preview", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: md2slack preview [flags] [file]")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&addr, "addr", "localhost:8080", "`address` to listen on")
+	converterFlags(flags, &opts)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	converter, err := newConverter(opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+		return 2
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "md2slack: previewing on http://%s/\n", listener.Addr())
+
+	server := &http.Server{Handler: newPreviewHandler(converter, flags.Arg(0))}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "md2slack: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// previewResult is the response of the convert endpoint.
+type previewResult struct {
+	Blocks     []slack.Block `json:"blocks"`
+	HTML       string        `json:"html"`
+	Text       string        `json:"text"`
+	BuilderURL string        `json:"builder_url"`
+	Violations []string      `json:"violations"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// previewFile is the response of the file endpoint.
+type previewFile struct {
+	Markdown string `json:"markdown"`
+	Modified string `json:"modified"`
+}
+
+// newPreviewHandler returns the handler of the preview page. It serves the page
+// at /, converts the markdown posted to /convert and returns the file, if any,
+// at /file, which the page polls to pick up changes.
+func newPreviewHandler(converter *slackUtil.Converter, file string) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, previewPage)
+	})
+
+	mux.HandleFunc("POST /convert", func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPreviewInputSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		writePreviewJSON(w, convertPreview(converter, string(data)))
+	})
+
+	mux.HandleFunc("GET /file", func(w http.ResponseWriter, r *http.Request) {
+		if file == "" {
+			http.NotFound(w, r)
+			return
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writePreviewJSON(w, previewFile{
+			Markdown: string(data),
+			Modified: info.ModTime().UTC().Format(time.RFC3339Nano),
+		})
+	})
+
+	return mux
+}
+
+func convertPreview(converter *slackUtil.Converter, markdown string) previewResult {
+	result := previewResult{Blocks: []slack.Block{}, Violations: []string{}}
+	blocks, err := converter.ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if blocks != nil {
+		result.Blocks = blocks
+	}
+	result.HTML = slackUtil.RenderBlocksToHTML(blocks)
+	result.Text = converter.ConvertMarkdownTextToFallbackText(markdown)
+	if u, err := slackUtil.BlockKitBuilderURL(blocks); err == nil {
+		result.BuilderURL = u
+	}
+	for _, violation := range slackUtil.ValidateBlocks(blocks) {
+		result.Violations = append(result.Violations, violation.Error())
+	}
+	return result
+}
+
+func writePreviewJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
+
+// previewPage is the editor page. The rendered blocks are shown in a sandboxed
+// frame, so that nothing in them can run scripts.
+const previewPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>md2slack preview</title>
+<style>
+* { box-sizing: border-box; }
+body { margin: 0; height: 100vh; display: flex; font: 14px -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; }
+#editor { flex: 1; padding: 16px; border: none; border-right: 1px solid #ddd; resize: none; font: 13px/1.5 Menlo, Consolas, monospace; outline: none; }
+#output { flex: 1; display: flex; flex-direction: column; min-width: 0; }
+#preview { flex: 1; border: none; border-bottom: 1px solid #ddd; }
+#status { padding: 6px 12px; background: #f8f8f8; color: #616061; }
+#status.error { background: #fdecea; color: #a4262c; }
+#status a { float: right; }
+#json { flex: 1; margin: 0; padding: 12px; overflow: auto; background: #f8f8f8; font: 12px/1.4 Menlo, Consolas, monospace; }
+</style>
+</head>
+<body>
+<textarea id="editor" spellcheck="false" placeholder="Write markdown here"></textarea>
+<div id="output">
+<iframe id="preview" sandbox title="Preview"></iframe>
+<div id="status"></div>
+<pre id="json"></pre>
+</div>
+<script>
+const editor = document.getElementById("editor");
+const preview = document.getElementById("preview");
+const status = document.getElementById("status");
+const json = document.getElementById("json");
+let pending = null;
+let latest = 0;
+let modified = "";
+
+async function convert() {
+	const request = ++latest;
+	const response = await fetch("convert", { method: "POST", body: editor.value });
+	if (request !== latest) return;
+	if (!response.ok) {
+		status.className = "error";
+		status.textContent = await response.text();
+		return;
+	}
+	const result = await response.json();
+	if (result.error) {
+		status.className = "error";
+		status.textContent = result.error;
+		return;
+	}
+	preview.srcdoc = result.html;
+	json.textContent = JSON.stringify({ text: result.text, blocks: result.blocks }, null, 2);
+	status.className = result.violations.length ? "error" : "";
+	status.textContent = result.blocks.length + " blocks" + (result.violations.length ? ": " + result.violations.join("; ") : "");
+	const link = document.createElement("a");
+	link.href = result.builder_url;
+	link.target = "_blank";
+	link.rel = "noopener";
+	link.textContent = "Open in Block Kit Builder";
+	status.appendChild(link);
+}
+
+function schedule() {
+	clearTimeout(pending);
+	pending = setTimeout(convert, 50);
+}
+
+async function watch() {
+	try {
+		const response = await fetch("file");
+		if (response.status === 404) return;
+		if (response.ok) {
+			const file = await response.json();
+			if (file.modified !== modified) {
+				modified = file.modified;
+				editor.value = file.markdown;
+				schedule();
+			}
+		}
+	} catch (e) {}
+	setTimeout(watch, 1000);
+}
+
+editor.addEventListener("input", schedule);
+watch();
+convert();
+</script>
+</body>
+</html>
+`
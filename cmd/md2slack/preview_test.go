@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// convertResponse decodes a previewResult, whose blocks are interfaces.
+type convertResponse struct {
+	Blocks     []json.RawMessage `json:"blocks"`
+	HTML       string            `json:"html"`
+	Text       string            `json:"text"`
+	BuilderURL string            `json:"builder_url"`
+	Violations []string          `json:"violations"`
+}
+
+func TestPreviewHandler(t *testing.T) {
+	converter, err := newConverter(options{heading: "header", lineBreaks: "newline", gfm: true, emoji: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "template.md")
+	if err := os.WriteFile(file, []byte("# Draft"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(newPreviewHandler(converter, file))
+	defer server.Close()
+
+	t.Run("page", func(t *testing.T) {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `<iframe id="preview" sandbox`) {
+			t.Errorf("GET / = %d:\n%s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/convert", "text/markdown", strings.NewReader("# Title\n\n~~gone~~"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result convertResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Blocks) != 2 || result.Text != "Title\ngone" || len(result.Violations) != 0 {
+			t.Errorf("result = %+v", result)
+		}
+		if !strings.Contains(result.HTML, `<h1 class="block header">Title</h1>`) || !strings.Contains(result.HTML, "<s>gone</s>") {
+			t.Errorf("html = %s", result.HTML)
+		}
+		if !strings.HasPrefix(result.BuilderURL, "https://app.slack.com/block-kit-builder/#") {
+			t.Errorf("builder_url = %q", result.BuilderURL)
+		}
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/convert", "text/markdown", strings.NewReader(strings.Repeat("para\n\n", 51)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result convertResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		if len(result.Violations) != 1 {
+			t.Errorf("violations = %q", result.Violations)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"/convert", "text/markdown", strings.NewReader(strings.Repeat("a", maxPreviewInputSize+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("POST /convert = %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		get := func() previewFile {
+			t.Helper()
+			resp, err := http.Get(server.URL + "/file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var f previewFile
+			if err := json.NewDecoder(resp.Body).Decode(&f); err != nil {
+				t.Fatal(err)
+			}
+			return f
+		}
+
+		before := get()
+		if before.Markdown != "# Draft" {
+			t.Errorf("markdown = %q", before.Markdown)
+		}
+		if err := os.WriteFile(file, []byte("# Final"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, time.Now(), time.Now().Add(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		after := get()
+		if after.Markdown != "# Final" || after.Modified == before.Modified {
+			t.Errorf("file = %+v, before %+v", after, before)
+		}
+	})
+}
+
+func TestPreviewHandlerWithoutFile(t *testing.T) {
+	converter, err := newConverter(options{heading: "header", lineBreaks: "newline"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(newPreviewHandler(converter, ""))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /file = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestRunPreviewUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"preview", "-heading", "huge"},
+		{"preview", "a.md", "b.md"},
+	} {
+		if code, _, _ := runCommand(t, "", nil, args...); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+}
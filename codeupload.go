@@ -0,0 +1,166 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+)
+
+// codePreviewLines is the number of lines of an uploaded code block shown in the message.
+const codePreviewLines = 10
+
+// codeFileExtensions are the file extensions of fence languages that differ from the language.
+var codeFileExtensions = map[string]string{
+	"bash":       "sh",
+	"shell":      "sh",
+	"javascript": "js",
+	"typescript": "ts",
+	"python":     "py",
+	"ruby":       "rb",
+	"rust":       "rs",
+	"kotlin":     "kt",
+	"markdown":   "md",
+	"yml":        "yaml",
+}
+
+// mrkdwnEscaper escapes the characters mrkdwn uses for control sequences.
+var mrkdwnEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// CodeFile is a code block to be uploaded as a file snippet.
+type CodeFile struct {
+	// Filename is the name of the file, such as "snippet.go".
+	Filename string
+	// Language is the language of the code block's fence, used as the snippet type.
+	// It is empty when the fence has none.
+	Language string
+	// Content is the code.
+	Content string
+}
+
+// UploadedFile is a file uploaded by a CodeUploader.
+type UploadedFile struct {
+	// ID is the Slack file ID.
+	ID string
+	// Name is the name the file is shown with.
+	Name string
+	// Permalink is the URL the message links the file with.
+	Permalink string
+}
+
+// CodeUploader uploads code blocks as file snippets.
+type CodeUploader interface {
+	UploadCode(ctx context.Context, file CodeFile) (*UploadedFile, error)
+}
+
+// WithCodeUpload uploads fenced code blocks longer than threshold characters with
+// uploader, and renders them as a preview of their first lines followed by a link to
+// the file. A threshold of 0 or less uploads the code blocks that do not fit a single
+// preformatted block. The code is uploaded every time it is converted, so converters
+// with this option are not meant for Stream or UpdateMarkdown.
+func WithCodeUpload(uploader CodeUploader, threshold int) Option {
+	return func(c *Converter) {
+		c.codeUploader = uploader
+		c.codeUploadThreshold = threshold
+	}
+}
+
+// NewSlackCodeUploader returns a CodeUploader that uploads code blocks with client's
+// files upload API. The files are not shared to a channel; linking them in a message
+// shares them with its readers.
+func NewSlackCodeUploader(client *slack.Client) CodeUploader {
+	return &slackCodeUploader{client: client}
+}
+
+type slackCodeUploader struct {
+	client *slack.Client
+}
+
+func (u *slackCodeUploader) UploadCode(ctx context.Context, file CodeFile) (*UploadedFile, error) {
+	summary, err := u.client.UploadFileContext(ctx, slack.UploadFileParameters{
+		Filename:    file.Filename,
+		Title:       file.Filename,
+		Content:     file.Content,
+		FileSize:    len(file.Content),
+		SnippetType: file.Language,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info, _, _, err := u.client.GetFileInfoContext(ctx, summary.ID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadedFile{ID: info.ID, Name: info.Name, Permalink: info.Permalink}, nil
+}
+
+// renderUploadedCode uploads a fenced code block that is over the upload threshold and
+// renders its preview and file link. It returns false when the code block is not uploaded.
+func (c *Converter) renderUploadedCode(ctx context.Context, code *ast.FencedCodeBlock, source []byte) ([]slack.Block, bool, error) {
+	var sb strings.Builder
+	lines := code.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		sb.Write(line.Value(source))
+	}
+	content := sb.String()
+
+	threshold := c.codeUploadThreshold
+	if threshold <= 0 {
+		threshold = c.maxPreformattedTextLength
+	}
+	if utf8.RuneCountInString(content) <= threshold {
+		return nil, false, nil
+	}
+
+	language := strings.ToLower(string(code.Language(source)))
+	file, err := c.codeUploader.UploadCode(ctx, CodeFile{
+		Filename: codeFilename(language),
+		Language: language,
+		Content:  content,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("upload code block: %w", err)
+	}
+
+	preview := content
+	if lines.Len() > codePreviewLines {
+		preview = ""
+		for i := 0; i < codePreviewLines; i++ {
+			line := lines.At(i)
+			preview += string(line.Value(source))
+		}
+	}
+	preview = truncateText(preview, c.maxPreformattedTextLength)
+
+	name := file.Name
+	if name == "" {
+		name = codeFilename(language)
+	}
+	count := fmt.Sprintf("%d lines", lines.Len())
+	if lines.Len() == 1 {
+		count = "1 line"
+	}
+	link := fmt.Sprintf(":page_facing_up: <%s|%s> · %s", file.Permalink, mrkdwnEscaper.Replace(name), count)
+
+	blocks := c.newPreformattedBlocks(preview)
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewTextBlockObject(slack.MarkdownType, link, false, false),
+	))
+	return blocks, true, nil
+}
+
+// codeFilename returns the name of the file a code block of a language is uploaded as.
+func codeFilename(language string) string {
+	if language == "" {
+		return "snippet.txt"
+	}
+	if ext, ok := codeFileExtensions[language]; ok {
+		return "snippet." + ext
+	}
+	return "snippet." + language
+}
@@ -0,0 +1,163 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slacktest"
+)
+
+// stubCodeUploader records the uploaded files and answers with a permalink per file.
+type stubCodeUploader struct {
+	files []CodeFile
+	err   error
+}
+
+func (u *stubCodeUploader) UploadCode(_ context.Context, file CodeFile) (*UploadedFile, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	u.files = append(u.files, file)
+	id := fmt.Sprintf("F%d", len(u.files))
+	return &UploadedFile{ID: id, Name: file.Filename, Permalink: "https://example.slack.com/files/U1/" + id}, nil
+}
+
+func TestWithCodeUpload(t *testing.T) {
+	long := strings.Repeat("fmt.Println(1)\n", 20)
+
+	tests := []struct {
+		name      string
+		markdown  string
+		threshold int
+		// want holds the markdown of the expected blocks, which are followed by a
+		// context block with wantLink for uploaded code
+		want      string
+		wantLink  string
+		wantFiles []CodeFile
+	}{
+		{
+			name:      "short code stays in the message",
+			markdown:  "```go\nfmt.Println(1)\n```",
+			threshold: 100,
+			want:      "```go\nfmt.Println(1)\n```",
+		},
+		{
+			name:      "long code is uploaded",
+			markdown:  "text\n\n```Go\n" + long + "```",
+			threshold: 100,
+			want:      "text\n\n```\n" + strings.Repeat("fmt.Println(1)\n", 10) + "```",
+			wantLink:  ":page_facing_up: <https://example.slack.com/files/U1/F1|snippet.go> · 20 lines",
+			wantFiles: []CodeFile{{Filename: "snippet.go", Language: "go", Content: long}},
+		},
+		{
+			name:      "code without a language",
+			markdown:  "```\n" + strings.Repeat("x", 50) + "\n```",
+			threshold: 10,
+			want:      "```\n" + strings.Repeat("x", 50) + "\n```",
+			wantLink:  ":page_facing_up: <https://example.slack.com/files/U1/F1|snippet.txt> · 1 line",
+			wantFiles: []CodeFile{{Filename: "snippet.txt", Content: strings.Repeat("x", 50) + "\n"}},
+		},
+		{
+			name:     "default threshold",
+			markdown: "```python\n" + long + "```",
+			want:     "```python\n" + long + "```",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploader := &stubCodeUploader{}
+			got, err := NewConverter(WithCodeUpload(uploader, tt.threshold)).ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatalf("ConvertMarkdownTextToBlocks() error = %v", err)
+			}
+
+			want, err := ConvertMarkdownTextToBlocks(tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantLink != "" {
+				want = append(want, slack.NewContextBlock("",
+					slack.NewTextBlockObject(slack.MarkdownType, tt.wantLink, false, false),
+				))
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("blocks = %s, want %s", gotJSON, wantJSON)
+			}
+
+			gotFiles, _ := json.Marshal(uploader.files)
+			wantFiles, _ := json.Marshal(tt.wantFiles)
+			if string(gotFiles) != string(wantFiles) {
+				t.Errorf("uploaded %s, want %s", gotFiles, wantFiles)
+			}
+		})
+	}
+}
+
+func TestWithCodeUploadErrors(t *testing.T) {
+	errUpload := errors.New("not_authed")
+	c := NewConverter(WithCodeUpload(&stubCodeUploader{err: errUpload}, 1))
+	if _, err := c.ConvertMarkdownTextToBlocks("```\nlong code\n```"); !errors.Is(err, errUpload) {
+		t.Errorf("ConvertMarkdownTextToBlocks() error = %v, want the upload error", err)
+	}
+}
+
+func TestWithCodeUploadTinyPreview(t *testing.T) {
+	c := NewConverter(WithCodeUpload(&stubCodeUploader{}, 1), WithMaxPreformattedTextLength(1))
+	blocks, err := c.ConvertMarkdownTextToBlocks("```\nlong code\n```")
+	if err != nil {
+		t.Fatalf("ConvertMarkdownTextToBlocks() error = %v", err)
+	}
+	if errs := ValidateBlocks(blocks); len(errs) > 0 {
+		t.Errorf("ConvertMarkdownTextToBlocks() = invalid blocks: %v", errs)
+	}
+}
+
+func TestSlackCodeUploader(t *testing.T) {
+	var uploaded, snippetType string
+	s := slacktest.NewTestServer(func(c slacktest.Customize) {
+		c.Handle("/files.getUploadURLExternal", func(w http.ResponseWriter, r *http.Request) {
+			snippetType = r.FormValue("snippet_type")
+			_, _ = fmt.Fprintf(w, `{"ok": true, "upload_url": "http://%s/upload", "file_id": "F1"}`, r.Host)
+		})
+		c.Handle("/upload", func(w http.ResponseWriter, r *http.Request) {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			content, _ := io.ReadAll(file)
+			uploaded = string(content)
+		})
+		c.Handle("/files.completeUploadExternal", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"ok": true, "files": [{"id": "F1", "title": "snippet.go"}]}`))
+		})
+		c.Handle("/files.info", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"ok": true, "file": {"id": %q, "name": "snippet.go", "permalink": "https://example.slack.com/files/U1/F1/snippet.go"}}`, r.FormValue("file"))
+		})
+	})
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	uploader := NewSlackCodeUploader(slack.New("xoxb-test", slack.OptionAPIURL(s.GetAPIURL())))
+	file, err := uploader.UploadCode(context.Background(), CodeFile{Filename: "snippet.go", Language: "go", Content: "package main\n"})
+	if err != nil {
+		t.Fatalf("UploadCode() error = %v", err)
+	}
+	want := UploadedFile{ID: "F1", Name: "snippet.go", Permalink: "https://example.slack.com/files/U1/F1/snippet.go"}
+	if *file != want {
+		t.Errorf("UploadCode() = %+v, want %+v", *file, want)
+	}
+	if uploaded != "package main\n" || snippetType != "go" {
+		t.Errorf("uploaded %q as %q", uploaded, snippetType)
+	}
+}
@@ -0,0 +1,188 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+var (
+	// ErrInputTooLarge is the LimitError.Err of markdown larger than WithMaxInputSize.
+	ErrInputTooLarge = errors.New("markdown input too large")
+	// ErrNestingTooDeep is the LimitError.Err of markdown nested deeper than WithMaxNestingDepth.
+	ErrNestingTooDeep = errors.New("markdown nesting too deep")
+	// ErrTooManyBlocks is the LimitError.Err of markdown rendering more blocks than WithMaxOutputBlocks.
+	ErrTooManyBlocks = errors.New("too many blocks")
+)
+
+// LimitError is returned when a conversion exceeds one of the Converter's limits.
+// Use errors.Is with ErrInputTooLarge, ErrNestingTooDeep or ErrTooManyBlocks to tell them apart.
+type LimitError struct {
+	// Err is the exceeded limit.
+	Err error
+	// Limit is the configured maximum.
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: limit is %d", e.Err, e.Limit)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// WithMaxInputSize sets the maximum size of the markdown input in bytes.
+// There is no limit by default.
+func WithMaxInputSize(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxInputSize = n
+		}
+	}
+}
+
+// WithMaxNestingDepth sets the maximum depth of nested lists, quotes and emphasis.
+// A flat list or quote is one level deep, a list in a list two levels, and a list
+// item with bold text two levels. There is no limit by default.
+func WithMaxNestingDepth(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxNestingDepth = n
+		}
+	}
+}
+
+// WithMaxOutputBlocks sets the maximum number of blocks a conversion may render.
+// Unlike WithBlocksPerMessage it fails the conversion. There is no limit by default.
+func WithMaxOutputBlocks(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxOutputBlocks = n
+		}
+	}
+}
+
+// Convert reads markdown from r and converts it to a slice of slack blocks.
+func Convert(ctx context.Context, r io.Reader) ([]slack.Block, error) {
+	return defaultConverter.Convert(ctx, r)
+}
+
+// Convert reads markdown from r and converts it to a slice of slack blocks. It stops
+// with the context's error when ctx is done, and with a *LimitError when the input
+// exceeds one of the Converter's limits.
+func (c *Converter) Convert(ctx context.Context, r io.Reader) ([]slack.Block, error) {
+	source, err := c.readInput(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return c.convert(ctx, source)
+}
+
+// convert parses source and converts it to a slice of slack blocks within the Converter's limits.
+func (c *Converter) convert(ctx context.Context, source []byte) ([]slack.Block, error) {
+	if c.maxInputSize > 0 && len(source) > c.maxInputSize {
+		return nil, &LimitError{Err: ErrInputTooLarge, Limit: c.maxInputSize}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	doc := c.markdown.Parser().Parse(text.NewReader(source))
+	return c.convertNode(ctx, doc, source)
+}
+
+// convertNode converts a parsed node to a slice of slack blocks within the Converter's limits.
+func (c *Converter) convertNode(ctx context.Context, n ast.Node, source []byte) ([]slack.Block, error) {
+	if c.maxNestingDepth > 0 && nestingDepth(n, c.maxNestingDepth) > c.maxNestingDepth {
+		return nil, &LimitError{Err: ErrNestingTooDeep, Limit: c.maxNestingDepth}
+	}
+
+	blocks, err := c.renderBlocks(ctx, n, source, true)
+	if err != nil {
+		return nil, err
+	}
+	if blocks == nil {
+		blocks = []slack.Block{}
+	}
+
+	return blocks, nil
+}
+
+// readInput reads r until EOF, checking ctx between reads and stopping as soon as
+// the input exceeds the maximum input size.
+func (c *Converter) readInput(ctx context.Context, r io.Reader) ([]byte, error) {
+	var source []byte
+	buf := make([]byte, 32*1024)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		n, err := r.Read(buf)
+		source = append(source, buf[:n]...)
+		if c.maxInputSize > 0 && len(source) > c.maxInputSize {
+			return nil, &LimitError{Err: ErrInputTooLarge, Limit: c.maxInputSize}
+		}
+		if err == io.EOF {
+			return source, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// nestingDepth returns the deepest nesting of lists, quotes and emphasis under root,
+// stopping once it exceeds limit. It walks the tree without recursion, so that deeply
+// nested input is rejected before it reaches the recursive renderers.
+func nestingDepth(root ast.Node, limit int) int {
+	maxDepth, depth := 0, 0
+	enter := func(n ast.Node) {
+		if isNestingNode(n) {
+			depth++
+			maxDepth = max(maxDepth, depth)
+		}
+	}
+	leave := func(n ast.Node) {
+		if isNestingNode(n) {
+			depth--
+		}
+	}
+
+	n := root
+	for maxDepth <= limit {
+		if child := n.FirstChild(); child != nil {
+			n = child
+			enter(n)
+			continue
+		}
+		for n != root && n.NextSibling() == nil {
+			leave(n)
+			n = n.Parent()
+		}
+		if n == root {
+			break
+		}
+		leave(n)
+		n = n.NextSibling()
+		enter(n)
+	}
+	return maxDepth
+}
+
+// isNestingNode reports whether n is a level of nesting counted by WithMaxNestingDepth.
+// List items are counted with their list, so a list in a list is two levels deep.
+func isNestingNode(n ast.Node) bool {
+	switch n.Kind() {
+	case ast.KindList, ast.KindBlockquote, ast.KindEmphasis, east.KindStrikethrough:
+		return true
+	}
+	return false
+}
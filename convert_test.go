@@ -0,0 +1,128 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestConvert(t *testing.T) {
+	got, err := Convert(context.Background(), strings.NewReader("# Title\n\nText\n\n- item"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantTypes := []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection, slack.MBTRichText}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("block count mismatch, got=%v, want=%v", len(got), len(wantTypes))
+	}
+	for i := range got {
+		if got[i].BlockType() != wantTypes[i] {
+			t.Errorf("block type mismatch at index=%d, got=%v, want=%v", i, got[i].BlockType(), wantTypes[i])
+		}
+	}
+}
+
+func TestConvertLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		markdown string
+		wantErr  error
+	}{
+		{
+			name:     "input within size",
+			opts:     []Option{WithMaxInputSize(10)},
+			markdown: "0123456789",
+		},
+		{
+			name:     "input too large",
+			opts:     []Option{WithMaxInputSize(10)},
+			markdown: strings.Repeat("a", 100*1024),
+			wantErr:  ErrInputTooLarge,
+		},
+		{
+			name:     "flat list within depth",
+			opts:     []Option{WithMaxNestingDepth(1)},
+			markdown: "- one\n- two\n\n> quote",
+		},
+		{
+			name:     "nested lists within depth",
+			opts:     []Option{WithMaxNestingDepth(2)},
+			markdown: "- one\n  - two\n- three\n  - four",
+		},
+		{
+			name:     "nested lists too deep",
+			opts:     []Option{WithMaxNestingDepth(2)},
+			markdown: "- 1\n  - 2\n    - 3",
+			wantErr:  ErrNestingTooDeep,
+		},
+		{
+			name:     "emphasis in a list too deep",
+			opts:     []Option{WithMaxNestingDepth(2)},
+			markdown: "- one\n  - **two**",
+			wantErr:  ErrNestingTooDeep,
+		},
+		{
+			name:     "nested quotes too deep",
+			opts:     []Option{WithMaxNestingDepth(32)},
+			markdown: strings.Repeat(">", 1000) + " deep",
+			wantErr:  ErrNestingTooDeep,
+		},
+		{
+			name:     "nested emphasis too deep",
+			opts:     []Option{WithMaxNestingDepth(32)},
+			markdown: strings.Repeat("*a ", 500) + strings.Repeat(" a*", 500),
+			wantErr:  ErrNestingTooDeep,
+		},
+		{
+			name:     "blocks within count",
+			opts:     []Option{WithMaxOutputBlocks(3)},
+			markdown: "one\n\ntwo\n\nthree",
+		},
+		{
+			name:     "too many blocks",
+			opts:     []Option{WithMaxOutputBlocks(3)},
+			markdown: strings.Repeat("paragraph\n\n", 1000),
+			wantErr:  ErrTooManyBlocks,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConverter(tt.opts...)
+			_, err := c.Convert(context.Background(), strings.NewReader(tt.markdown))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error mismatch, got=%v, want=%v", err, tt.wantErr)
+			}
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) {
+				t.Errorf("error is not a *LimitError, got=%T", err)
+			}
+
+			if _, err := c.ConvertMarkdownTextToBlocks(tt.markdown); !errors.Is(err, tt.wantErr) {
+				t.Errorf("string conversion error mismatch, got=%v, want=%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvertCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Convert(ctx, strings.NewReader("# Title"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error mismatch, got=%v, want=%v", err, context.Canceled)
+	}
+}
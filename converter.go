@@ -0,0 +1,199 @@
+package util
+
+import (
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+)
+
+// HeadingStyle controls how markdown headings are rendered.
+type HeadingStyle int
+
+const (
+	// HeadingStyleHeader renders headings as header blocks.
+	HeadingStyleHeader HeadingStyle = iota
+	// HeadingStyleBold renders headings as sections with bold mrkdwn text,
+	// which keeps inline formatting and links of the heading.
+	HeadingStyleBold
+)
+
+// LineBreakMode controls how soft line breaks inside paragraphs are rendered.
+type LineBreakMode int
+
+const (
+	// LineBreakNewline keeps soft line breaks as newlines, as Slack users write messages.
+	LineBreakNewline LineBreakMode = iota
+	// LineBreakSpace joins soft line breaks with a space, as CommonMark renders them.
+	// Hard line breaks are still rendered as newlines.
+	LineBreakSpace
+)
+
+// Hook is called with the blocks rendered for each markdown node that renders
+// blocks. These are usually the direct children of the document, but the
+// children of block nodes without a renderer, such as containers of unknown
+// extensions, are rendered and hooked one by one. The returned blocks replace
+// the rendered ones, so a hook can modify, drop or add blocks, for example to
+// set block_ids or insert dividers.
+type Hook func(n ast.Node, blocks []slack.Block) []slack.Block
+
+// Converter converts markdown to slack blocks with its own settings.
+// A Converter is immutable once created and is safe for concurrent use.
+type Converter struct {
+	markdown                  goldmark.Markdown
+	headingStyle              HeadingStyle
+	emoji                     bool
+	lineBreaks                LineBreakMode
+	maxSectionTextLength      int
+	maxHeaderTextLength       int
+	maxPreformattedTextLength int
+	maxFallbackTextLength     int
+	maxBlocks                 int
+	maxInputSize              int
+	maxNestingDepth           int
+	maxOutputBlocks           int
+	extensions                []goldmark.Extender
+	parserOptions             []parser.Option
+	hooks                     []Hook
+	blockRenderers            map[ast.NodeKind]BlockRenderer
+	inlineRenderers           map[ast.NodeKind]InlineRenderer
+	renderFormat              RenderFormat
+	codeUploader              CodeUploader
+	codeUploadThreshold       int
+}
+
+// Option configures a Converter.
+type Option func(*Converter)
+
+// WithHeadingStyle sets how headings are rendered. The default is HeadingStyleHeader.
+func WithHeadingStyle(style HeadingStyle) Option {
+	return func(c *Converter) {
+		c.headingStyle = style
+	}
+}
+
+// WithEmoji sets whether emoji shortcodes are rendered in plain text such as headers.
+// The default is true.
+func WithEmoji(enabled bool) Option {
+	return func(c *Converter) {
+		c.emoji = enabled
+	}
+}
+
+// WithLineBreaks sets how soft line breaks are rendered. The default is LineBreakNewline.
+func WithLineBreaks(mode LineBreakMode) Option {
+	return func(c *Converter) {
+		c.lineBreaks = mode
+	}
+}
+
+// WithMaxSectionTextLength sets the number of characters after which section text
+// is continued in another section. The default is MaxSectionTextLength.
+func WithMaxSectionTextLength(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxSectionTextLength = n
+		}
+	}
+}
+
+// WithMaxHeaderTextLength sets the number of characters after which header text
+// overflows into a following section. The default is MaxHeaderTextLength.
+func WithMaxHeaderTextLength(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxHeaderTextLength = n
+		}
+	}
+}
+
+// WithMaxPreformattedTextLength sets the number of characters after which code
+// is continued in another block. The default is MaxPreformattedTextLength.
+func WithMaxPreformattedTextLength(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxPreformattedTextLength = n
+		}
+	}
+}
+
+// WithMaxFallbackTextLength sets the maximum number of characters of the fallback
+// text. The default is MaxFallbackTextLength.
+func WithMaxFallbackTextLength(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxFallbackTextLength = n
+		}
+	}
+}
+
+// WithBlocksPerMessage sets the maximum number of blocks per chunk returned by
+// ConvertMarkdownTextToBlockChunks. The default is MaxMessageBlocks.
+func WithBlocksPerMessage(n int) Option {
+	return func(c *Converter) {
+		if n > 0 {
+			c.maxBlocks = n
+		}
+	}
+}
+
+// WithExtensions adds goldmark extensions, such as extension.GFM, to the markdown parser.
+// Nodes of extensions without a renderer are rendered as text.
+func WithExtensions(extensions ...goldmark.Extender) Option {
+	return func(c *Converter) {
+		c.extensions = append(c.extensions, extensions...)
+	}
+}
+
+// WithParserOptions adds goldmark parser options, such as parser.WithAutoHeadingID.
+func WithParserOptions(opts ...parser.Option) Option {
+	return func(c *Converter) {
+		c.parserOptions = append(c.parserOptions, opts...)
+	}
+}
+
+// WithMarkdown sets the goldmark instance whose parser is used to parse markdown.
+// WithExtensions and WithParserOptions are ignored when it is set, since the
+// instance is expected to be configured already.
+func WithMarkdown(m goldmark.Markdown) Option {
+	return func(c *Converter) {
+		c.markdown = m
+	}
+}
+
+// WithHooks adds hooks that are called with the blocks rendered for each
+// markdown node, as described for Hook, in the order they are given.
+func WithHooks(hooks ...Hook) Option {
+	return func(c *Converter) {
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+// NewConverter creates a Converter with the given options.
+func NewConverter(opts ...Option) *Converter {
+	c := &Converter{
+		headingStyle:              HeadingStyleHeader,
+		emoji:                     true,
+		lineBreaks:                LineBreakNewline,
+		maxSectionTextLength:      MaxSectionTextLength,
+		maxHeaderTextLength:       MaxHeaderTextLength,
+		maxPreformattedTextLength: MaxPreformattedTextLength,
+		maxFallbackTextLength:     MaxFallbackTextLength,
+		maxBlocks:                 MaxMessageBlocks,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.markdown == nil {
+		c.markdown = goldmark.New(
+			goldmark.WithExtensions(c.extensions...),
+			goldmark.WithParserOptions(c.parserOptions...),
+		)
+	}
+
+	return c
+}
+
+// defaultConverter backs the package level conversion functions.
+var defaultConverter = NewConverter()
@@ -0,0 +1,376 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+)
+
+func TestConverterOptions(t *testing.T) {
+	boolFalse := false
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		markdown string
+		want     []slack.Block
+	}{
+		{
+			name:     "default",
+			markdown: "# Title\nline one\nline two",
+			want: []slack.Block{
+				&slack.HeaderBlock{
+					Type: slack.MBTHeader,
+					Text: &slack.TextBlockObject{Type: slack.PlainTextType, Text: "Title", Emoji: &boolTrue},
+				},
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "line one\nline two"},
+				},
+			},
+		},
+		{
+			name:     "heading style bold",
+			opts:     []Option{WithHeadingStyle(HeadingStyleBold)},
+			markdown: "## Release [v1](https://example.com)",
+			want: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "*Release <https://example.com|v1>*"},
+				},
+			},
+		},
+		{
+			name:     "emoji disabled",
+			opts:     []Option{WithEmoji(false)},
+			markdown: "# Title :tada:",
+			want: []slack.Block{
+				&slack.HeaderBlock{
+					Type: slack.MBTHeader,
+					Text: &slack.TextBlockObject{Type: slack.PlainTextType, Text: "Title :tada:", Emoji: &boolFalse},
+				},
+			},
+		},
+		{
+			name:     "line breaks as spaces",
+			opts:     []Option{WithLineBreaks(LineBreakSpace)},
+			markdown: "line one\nline two  \nline three",
+			want: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "line one line two\nline three"},
+				},
+			},
+		},
+		{
+			name:     "section text limit",
+			opts:     []Option{WithMaxSectionTextLength(11)},
+			markdown: "First one. Second one.",
+			want: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "First one."},
+				},
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "Second one."},
+				},
+			},
+		},
+		{
+			name:     "extensions",
+			opts:     []Option{WithExtensions(extension.Strikethrough)},
+			markdown: "~~old~~ new",
+			want: []slack.Block{
+				&slack.SectionBlock{
+					Type: slack.MBTSection,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "~old~ new"},
+				},
+			},
+		},
+		{
+			name: "hooks",
+			opts: []Option{WithHooks(func(n ast.Node, blocks []slack.Block) []slack.Block {
+				if n.Kind() == ast.KindHeading {
+					return append([]slack.Block{slack.NewDividerBlock()}, blocks...)
+				}
+				return blocks
+			})},
+			markdown: "# Title",
+			want: []slack.Block{
+				&slack.DividerBlock{Type: slack.MBTDivider},
+				&slack.HeaderBlock{
+					Type: slack.MBTHeader,
+					Text: &slack.TextBlockObject{Type: slack.PlainTextType, Text: "Title", Emoji: &boolTrue},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConverter(tt.opts...).ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("block count mismatch, got=%v, want=%v", len(got), len(tt.want))
+			}
+
+			for i := range got {
+				if got[i].BlockType() != tt.want[i].BlockType() {
+					t.Errorf("block type mismatch at index=%d, got=%v, want=%v", i, got[i].BlockType(), tt.want[i].BlockType())
+					continue
+				}
+
+				var gotText, wantText *slack.TextBlockObject
+				switch block := got[i].(type) {
+				case *slack.HeaderBlock:
+					gotText, wantText = block.Text, tt.want[i].(*slack.HeaderBlock).Text
+				case *slack.SectionBlock:
+					gotText, wantText = block.Text, tt.want[i].(*slack.SectionBlock).Text
+				}
+				if gotText == nil {
+					continue
+				}
+				if gotText.Text != wantText.Text {
+					t.Errorf("text mismatch at index=%d, got=%q, want=%q", i, gotText.Text, wantText.Text)
+				}
+				if (gotText.Emoji == nil) != (wantText.Emoji == nil) || (gotText.Emoji != nil && *gotText.Emoji != *wantText.Emoji) {
+					t.Errorf("emoji mismatch at index=%d, got=%v, want=%v", i, gotText.Emoji, wantText.Emoji)
+				}
+			}
+		})
+	}
+}
+
+func TestConverterChunksAndFallback(t *testing.T) {
+	c := NewConverter(WithBlocksPerMessage(2), WithMaxFallbackTextLength(21))
+	markdown := "# Title\n\nOne\n\nTwo\n\nThree\n\nFour"
+
+	chunks, err := c.ConvertMarkdownTextToBlockChunks(markdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(chunks) != 3 {
+		t.Errorf("chunk count mismatch, got=%v, want=%v", len(chunks), 3)
+	}
+
+	if got, want := c.ConvertMarkdownTextToFallbackText(markdown), "Title\nOne Two Three…"; got != want {
+		t.Errorf("fallback text mismatch, got=%q, want=%q", got, want)
+	}
+}
+
+func TestConverterConcurrentUse(t *testing.T) {
+	c := NewConverter(WithHeadingStyle(HeadingStyleBold))
+	markdown := "# Title\n\n- **one**\n- two\n  - three\n\n```\ncode\n```\n\n" + strings.Repeat("text ", 100)
+
+	want, err := c.ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := c.ConvertMarkdownTextToBlocks(markdown)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if len(got) != len(want) {
+				t.Errorf("block count mismatch, got=%v, want=%v", len(got), len(want))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConverterGoldmarkExtensions(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		markdown  string
+		wantTypes []slack.MessageBlockType
+		wantTexts []string
+	}{
+		{
+			name:      "strikethrough",
+			opts:      []Option{WithExtensions(extension.GFM)},
+			markdown:  "~~old~~ new",
+			wantTypes: []slack.MessageBlockType{slack.MBTSection},
+			wantTexts: []string{"~old~ new"},
+		},
+		{
+			name:      "task list",
+			opts:      []Option{WithExtensions(extension.GFM)},
+			markdown:  "- [x] done\n- [ ] todo",
+			wantTypes: []slack.MessageBlockType{slack.MBTRichText},
+		},
+		{
+			name:      "table",
+			opts:      []Option{WithExtensions(extension.GFM)},
+			markdown:  "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			wantTypes: []slack.MessageBlockType{slack.MBTTable},
+		},
+		{
+			name:      "definition list falls back to text",
+			opts:      []Option{WithExtensions(extension.DefinitionList)},
+			markdown:  "Term\n: Definition",
+			wantTypes: []slack.MessageBlockType{slack.MBTSection, slack.MBTSection},
+			wantTexts: []string{"Term", "Definition"},
+		},
+		{
+			name:      "typographer",
+			opts:      []Option{WithExtensions(extension.Typographer)},
+			markdown:  "Wait... \"quoted\"",
+			wantTypes: []slack.MessageBlockType{slack.MBTSection},
+			wantTexts: []string{"Wait… “quoted”"},
+		},
+		{
+			name:      "parser options",
+			opts:      []Option{WithParserOptions(parser.WithAttribute())},
+			markdown:  "# Title {#custom-id}",
+			wantTypes: []slack.MessageBlockType{slack.MBTHeader},
+			wantTexts: []string{"Title"},
+		},
+		{
+			name:      "markdown instance",
+			opts:      []Option{WithMarkdown(goldmark.New(goldmark.WithExtensions(extension.Strikethrough)))},
+			markdown:  "~~old~~",
+			wantTypes: []slack.MessageBlockType{slack.MBTSection},
+			wantTexts: []string{"~old~"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConverter(tt.opts...).ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(got) != len(tt.wantTypes) {
+				t.Fatalf("block count mismatch, got=%v, want=%v", len(got), len(tt.wantTypes))
+			}
+			for i := range got {
+				if got[i].BlockType() != tt.wantTypes[i] {
+					t.Errorf("block type mismatch at index=%d, got=%v, want=%v", i, got[i].BlockType(), tt.wantTypes[i])
+				}
+				if i >= len(tt.wantTexts) {
+					continue
+				}
+				var text string
+				switch block := got[i].(type) {
+				case *slack.HeaderBlock:
+					text = block.Text.Text
+				case *slack.SectionBlock:
+					text = block.Text.Text
+				}
+				if text != tt.wantTexts[i] {
+					t.Errorf("text mismatch at index=%d, got=%q, want=%q", i, text, tt.wantTexts[i])
+				}
+			}
+		})
+	}
+}
+
+// TestConverterCommonMarkBlocks covers the CommonMark nodes that are rendered besides
+// the ones of extensions: thematic breaks, indented code, html and autolinks.
+func TestConverterCommonMarkBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "thematic break",
+			markdown: "Above\n\n---\n\nBelow",
+			want:     `[{"type":"section","text":{"type":"mrkdwn","text":"Above"}},{"type":"divider"},{"type":"section","text":{"type":"mrkdwn","text":"Below"}}]`,
+		},
+		{
+			name:     "indented code block",
+			markdown: "    indented code",
+			want:     `[{"type":"rich_text","elements":[{"type":"rich_text_preformatted","elements":[{"type":"text","text":"indented code\n"}],"border":0}]}]`,
+		},
+		{
+			name:     "html block is dropped",
+			markdown: "<div>\nraw\n</div>\n\nText",
+			want:     `[{"type":"section","text":{"type":"mrkdwn","text":"Text"}}]`,
+		},
+		{
+			name:     "autolink",
+			markdown: "See <https://example.com>",
+			want:     `[{"type":"section","text":{"type":"mrkdwn","text":"See \u003chttps://example.com\u003e"}}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotJSON, _ := json.Marshal(got); string(gotJSON) != tt.want {
+				t.Errorf("blocks mismatch, got=%s, want=%s", gotJSON, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterGFMTable(t *testing.T) {
+	c := NewConverter(WithExtensions(extension.GFM))
+
+	got, err := c.ConvertMarkdownTextToBlocks("| Name | Score |\n|:-----|------:|\n| **a** | 1 |\n| b | |")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	table, ok := got[0].(*slack.TableBlock)
+	if !ok {
+		t.Fatalf("block is not a table, got=%T", got[0])
+	}
+
+	wantAligns := []slack.ColumnAlignment{slack.ColumnAlignmentLeft, slack.ColumnAlignmentRight}
+	if len(table.ColumnSettings) != len(wantAligns) {
+		t.Fatalf("column count mismatch, got=%v, want=%v", len(table.ColumnSettings), len(wantAligns))
+	}
+	for i, setting := range table.ColumnSettings {
+		if setting.Align != wantAligns[i] {
+			t.Errorf("alignment mismatch at index=%d, got=%v, want=%v", i, setting.Align, wantAligns[i])
+		}
+	}
+
+	wantCells := [][]string{{"Name", "Score"}, {"a", "1"}, {"b", " "}}
+	if len(table.Rows) != len(wantCells) {
+		t.Fatalf("row count mismatch, got=%v, want=%v", len(table.Rows), len(wantCells))
+	}
+	for i, row := range table.Rows {
+		for j, cell := range row {
+			section := cell.Elements[0].(*slack.RichTextSection)
+			textElem := section.Elements[0].(*slack.RichTextSectionTextElement)
+			if textElem.Text != wantCells[i][j] {
+				t.Errorf("cell text mismatch at row=%d, column=%d, got=%q, want=%q", i, j, textElem.Text, wantCells[i][j])
+			}
+		}
+	}
+
+	header := table.Rows[0][0].Elements[0].(*slack.RichTextSection).Elements[0].(*slack.RichTextSectionTextElement)
+	if header.Style == nil || !header.Style.Bold {
+		t.Errorf("header cell is not bold")
+	}
+
+	if errs := ValidateBlocks(got); errs != nil {
+		t.Errorf("table is invalid: %v", errs)
+	}
+}
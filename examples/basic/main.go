@@ -30,9 +30,13 @@ func main() {
 		panic(err)
 	}
 
+	// Plain text summary for notifications and screen readers
+	fallback := slackUtil.ConvertMarkdownTextToFallbackText(markdown)
+
 	// Send message to Slack
 	_, _, err = api.PostMessage(
 		"CHANNEL_ID",
+		slack.MsgOptionText(fallback, false),
 		slack.MsgOptionBlocks(blocks...),
 	)
 	if err != nil {
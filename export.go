@@ -0,0 +1,283 @@
+package util
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// exportDayPattern matches the per-day message files of an export, "<channel>/<YYYY-MM-DD>.json".
+var exportDayPattern = regexp.MustCompile(`^([^/\\]+)/([0-9]{4}-[0-9]{2}-[0-9]{2})\.json$`)
+
+// exportChannelFiles are the files of an export that list its conversations: public
+// and private channels, group DMs and DMs, whose folders are named by their ID.
+var exportChannelFiles = []string{"channels.json", "groups.json", "mpims.json", "dms.json"}
+
+type exportChannel struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// exportMessage is a message of an export and the day of the file it was read from.
+type exportMessage struct {
+	slack.Msg
+	day string
+}
+
+// ConvertExportFileToMarkdown converts the Slack workspace export zip archive at path,
+// as ConvertExportToMarkdown does.
+func ConvertExportFileToMarkdown(path, dir string, opts ...MarkdownOption) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	return ConvertExportToMarkdown(&archive.Reader, dir, opts...)
+}
+
+// ConvertExportToMarkdown converts a Slack workspace export to markdown files in dir,
+// one per channel and day at "<channel>/<YYYY-MM-DD>.md".
+//
+// Thread replies are nested under their parent message, in the file of the parent's
+// day. User and channel mentions are resolved to the names in users.json and
+// channels.json; mentions that cannot be resolved are written as opts configure.
+// Files and reactions are summarized below each message.
+func ConvertExportToMarkdown(archive *zip.Reader, dir string, opts ...MarkdownOption) error {
+	names := MentionNames{Users: map[string]string{}, Channels: map[string]string{}}
+	var users []slack.User
+	if err := readExportFile(archive, "users.json", &users); err != nil {
+		return err
+	}
+	for i := range users {
+		names.Users[users[i].ID] = userDisplayName(&users[i])
+	}
+	for _, name := range exportChannelFiles {
+		var channels []exportChannel
+		if err := readExportFile(archive, name, &channels); err != nil {
+			return err
+		}
+		for _, channel := range channels {
+			if channel.Name != "" {
+				names.Channels[channel.ID] = channel.Name
+			}
+		}
+	}
+	opts = append(opts[:len(opts):len(opts)], MarkdownMentionLookup(names))
+
+	channels := map[string][]exportMessage{}
+	for _, file := range archive.File {
+		match := exportDayPattern.FindStringSubmatch(file.Name)
+		if match == nil || match[1] == "." || match[1] == ".." {
+			continue
+		}
+		var messages []slack.Msg
+		if err := readExportZipFile(file, &messages); err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			channels[match[1]] = append(channels[match[1]], exportMessage{Msg: msg, day: match[2]})
+		}
+	}
+
+	for channel, messages := range channels {
+		days, err := exportChannelToMarkdown(channel, messages, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", channel, err)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, channel), 0o755); err != nil {
+			return err
+		}
+		for day, md := range days {
+			if err := os.WriteFile(filepath.Join(dir, channel, day+".md"), []byte(md), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// exportChannelToMarkdown renders the messages of a channel and returns the markdown by day.
+func exportChannelToMarkdown(channel string, messages []exportMessage, opts []MarkdownOption) (map[string]string, error) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		a, b := exportTimestamp(messages[i].Timestamp), exportTimestamp(messages[j].Timestamp)
+		if a != b {
+			return a < b
+		}
+		return messages[i].Timestamp < messages[j].Timestamp
+	})
+
+	parents := map[string]bool{}
+	for _, msg := range messages {
+		if !isThreadReply(&msg.Msg) {
+			parents[msg.Timestamp] = true
+		}
+	}
+	replies := map[string][]exportMessage{}
+	var top []exportMessage
+	for _, msg := range messages {
+		if isThreadReply(&msg.Msg) && parents[msg.ThreadTimestamp] {
+			replies[msg.ThreadTimestamp] = append(replies[msg.ThreadTimestamp], msg)
+		} else {
+			top = append(top, msg)
+		}
+	}
+
+	w := newMarkdownWriter(opts)
+	parts := map[string][]string{}
+	for _, msg := range top {
+		md, err := w.exportMessage(msg, msg.day, opts)
+		if err != nil {
+			return nil, err
+		}
+		var thread []string
+		for _, reply := range replies[msg.Timestamp] {
+			replyMD, err := w.exportMessage(reply, msg.day, opts)
+			if err != nil {
+				return nil, err
+			}
+			thread = append(thread, quoteLines(replyMD))
+		}
+		if len(thread) > 0 {
+			md += "\n\n" + strings.Join(thread, "\n>\n")
+		}
+		parts[msg.day] = append(parts[msg.day], md)
+	}
+
+	days := map[string]string{}
+	for day, mds := range parts {
+		title := "# #" + escapeInlineMarkdown(channel) + " — " + day
+		days[day] = title + "\n\n" + strings.Join(mds, "\n\n---\n\n") + "\n"
+	}
+	return days, nil
+}
+
+// exportMessage renders a message with a line naming its author and time. The date is
+// included when it is not day, the day of the file the message is written to.
+func (w *markdownWriter) exportMessage(msg exportMessage, day string, opts []MarkdownOption) (string, error) {
+	body, err := ConvertMessageToMarkdown(&msg.Msg, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	sent := time.Unix(exportTimestamp(msg.Timestamp), 0).UTC()
+	when := sent.Format("15:04")
+	if msg.day != day {
+		when = msg.day + " " + when
+	}
+	parts := []string{"**" + w.exportAuthor(&msg.Msg) + "** · " + when, body}
+
+	var files []string
+	for _, file := range msg.Files {
+		files = append(files, exportFile(file))
+	}
+	if len(files) > 0 {
+		parts = append(parts, "*Files:* "+strings.Join(files, ", "))
+	}
+
+	var reactions []string
+	for _, reaction := range msg.Reactions {
+		reactions = append(reactions, w.cfg.emoji(reaction.Name, "")+" "+strconv.Itoa(reaction.Count))
+	}
+	if len(reactions) > 0 {
+		parts = append(parts, "*Reactions:* "+strings.Join(reactions, ", "))
+	}
+
+	return joinNonEmpty(parts, "\n\n"), nil
+}
+
+// exportAuthor names the author of msg as a user mention or, for bot messages, by
+// the name the bot posted with.
+func (w *markdownWriter) exportAuthor(msg *slack.Msg) string {
+	switch {
+	case msg.User != "":
+		return w.cfg.user(msg.User)
+	case msg.Username != "":
+		return escapeInlineMarkdown(msg.Username)
+	case msg.BotID != "":
+		return escapeInlineMarkdown(msg.BotID)
+	}
+	return "unknown"
+}
+
+// exportFile links to a file shared in a message. Files without a URL, such as those
+// hidden by the workspace's plan, are only named.
+func exportFile(file slack.File) string {
+	name := file.Title
+	if name == "" {
+		name = file.Name
+	}
+	name = escapeInlineMarkdown(name)
+	url := file.Permalink
+	if url == "" {
+		url = file.URLPrivate
+	}
+	if url == "" {
+		return name
+	}
+	return "[" + name + "](" + linkDestination(url) + ")"
+}
+
+func isThreadReply(msg *slack.Msg) bool {
+	return msg.ThreadTimestamp != "" && msg.ThreadTimestamp != msg.Timestamp
+}
+
+// exportTimestamp returns the seconds of a message timestamp such as "1700000000.000100".
+func exportTimestamp(ts string) int64 {
+	seconds, _, _ := strings.Cut(ts, ".")
+	n, _ := strconv.ParseInt(seconds, 10, 64)
+	return n
+}
+
+// quoteLines quotes md as a block quote, without trailing spaces on blank lines.
+func quoteLines(md string) string {
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// readExportFile decodes the JSON file name at the root of archive into v. A missing
+// file is not an error, as exports only include the files of what they contain.
+func readExportFile(archive *zip.Reader, name string, v any) error {
+	for _, file := range archive.File {
+		if path.Clean(file.Name) == name {
+			return readExportZipFile(file, v)
+		}
+	}
+	return nil
+}
+
+func readExportZipFile(file *zip.File, v any) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", file.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var exportFixture = map[string]string{
+	"users.json": `[
+		{"id": "U1", "name": "alice", "real_name": "Alice Doe", "profile": {"display_name": "alice"}},
+		{"id": "U2", "name": "bob", "real_name": "Bob Roe", "profile": {"display_name": ""}}
+	]`,
+	"channels.json": `[{"id": "C1", "name": "general"}, {"id": "C2", "name": "random"}]`,
+	"general/2023-11-14.json": `[
+		{"type": "message", "user": "U1", "text": "Hi <@U2>, see <#C2> and *this*", "ts": "1700000000.000100",
+			"thread_ts": "1700000000.000100", "reply_count": 2,
+			"reactions": [{"name": "tada", "count": 2, "users": ["U1", "U2"]}]},
+		{"type": "message", "user": "U2", "text": "On it", "ts": "1700000060.000200", "thread_ts": "1700000000.000100"},
+		{"type": "message", "subtype": "bot_message", "username": "deploy-bot", "text": "Deployed", "ts": "1700000120.000300",
+			"files": [{"id": "F1", "name": "log.txt", "title": "Deploy log", "permalink": "https://example.slack.com/files/F1"}]}
+	]`,
+	"general/2023-11-15.json": `[
+		{"type": "message", "user": "U1", "text": "Done\n• one\n• two", "ts": "1700060000.000100", "thread_ts": "1700000000.000100"},
+		{"type": "message", "user": "U3", "text": "Late reply", "ts": "1700060100.000100", "thread_ts": "1690000000.000100"}
+	]`,
+	"../evil/2023-11-14.json":                 `[{"type": "message", "text": "outside"}]`,
+	"integration_logs.json":                   `[]`,
+	"general/canvas_in_the_conversation.json": `{}`,
+}
+
+func newExportArchive(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestConvertExportToMarkdown(t *testing.T) {
+	r := newExportArchive(t, exportFixture)
+	archive, err := zip.NewReader(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := ConvertExportToMarkdown(archive, dir); err != nil {
+		t.Fatalf("ConvertExportToMarkdown() error = %v", err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{
+			file: "general/2023-11-14.md",
+			want: "# #general — 2023-11-14\n\n" +
+				"**@alice** · 22:13\n\nHi @Bob Roe, see #random and **this**\n\n*Reactions:* :tada: 2\n\n" +
+				"> **@Bob Roe** · 22:14\n>\n> On it\n>\n" +
+				"> **@alice** · 2023-11-15 14:53\n>\n> Done\n> - one\n> - two\n\n" +
+				"---\n\n" +
+				"**deploy-bot** · 22:15\n\nDeployed\n\n*Files:* [Deploy log](https://example.slack.com/files/F1)\n",
+		},
+		{
+			file: "general/2023-11-15.md",
+			want: "# #general — 2023-11-15\n\n**<@U3>** · 14:55\n\nLate reply\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(dir, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("%s =\n%s\nwant\n%s", tt.file, got, tt.want)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "general" {
+		t.Errorf("dir holds %v, want only general", entries)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil")); !os.IsNotExist(err) {
+		t.Errorf("entry outside the archive root was written")
+	}
+}
+
+func TestConvertExportFileToMarkdown(t *testing.T) {
+	r := newExportArchive(t, map[string]string{
+		"random/2023-11-14.json": `[{"type": "message", "user": "U1", "text": "hello", "ts": "1700000000.000100"}]`,
+	})
+	path := filepath.Join(t.TempDir(), "export.zip")
+	data := make([]byte, r.Size())
+	if _, err := r.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := ConvertExportFileToMarkdown(path, dir, MarkdownUserFormat(func(id string) string { return "user " + id })); err != nil {
+		t.Fatalf("ConvertExportFileToMarkdown() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "random", "2023-11-14.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# #random — 2023-11-14\n\n**user U1** · 22:13\n\nhello\n"
+	if string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if err := ConvertExportFileToMarkdown(filepath.Join(t.TempDir(), "missing.zip"), dir); err == nil {
+		t.Error("expected an error for a missing archive")
+	}
+}
+
+func TestConvertExportToMarkdownInvalidJSON(t *testing.T) {
+	r := newExportArchive(t, map[string]string{"users.json": `{`})
+	archive, err := zip.NewReader(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertExportToMarkdown(archive, t.TempDir()); err == nil {
+		t.Error("expected an error for invalid users.json")
+	}
+}
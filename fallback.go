@@ -0,0 +1,136 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// MaxFallbackTextLength is the maximum number of characters in a notification fallback text.
+const MaxFallbackTextLength = 300
+
+// mentionPattern matches Slack mention syntax such as <@U123>, <#C123|general> and <!here>.
+var mentionPattern = regexp.MustCompile(`<[@#!][^<>\s]*>`)
+
+// ConvertMarkdownTextToFallbackText converts a markdown text to a plain text summary
+// suitable for the top-level text of a message, which Slack uses for push notifications
+// and screen readers. The summary is the first heading followed by the leading text of
+// the document with formatting stripped, capped at MaxFallbackTextLength characters.
+// Mentions are kept in Slack's mention syntax so that notifications still resolve them.
+func ConvertMarkdownTextToFallbackText(markdown string) string {
+	return defaultConverter.ConvertMarkdownTextToFallbackText(markdown)
+}
+
+// ConvertMarkdownTextToFallbackText converts a markdown text to a plain text summary
+// capped at the converter's maximum fallback text length.
+func (c *Converter) ConvertMarkdownTextToFallbackText(markdown string) string {
+	source := []byte(markdown)
+	doc := c.markdown.Parser().Parse(text.NewReader(source))
+	return c.ConvertNodeToFallbackText(doc, source)
+}
+
+// ConvertNodeToFallbackText converts a parsed markdown node, usually an ast.Document,
+// to a plain text summary. source is the markdown the node was parsed from.
+func (c *Converter) ConvertNodeToFallbackText(doc ast.Node, source []byte) string {
+	var title string
+	var body []string
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		plain := collapseSpaces(extractPlainText(n, source))
+		if plain == "" {
+			continue
+		}
+		if title == "" && n.Kind() == ast.KindHeading {
+			title = plain
+			continue
+		}
+		body = append(body, plain)
+	}
+
+	summary := strings.Join(body, " ")
+	if title != "" {
+		summary = strings.TrimSpace(title + "\n" + summary)
+	}
+
+	return truncateText(summary, c.maxFallbackTextLength)
+}
+
+// extractPlainText returns the text of a node and its descendants with all
+// markdown formatting stripped.
+func extractPlainText(n ast.Node, source []byte) string {
+	var sb strings.Builder
+
+	var process func(ast.Node)
+	process = func(node ast.Node) {
+		switch node.Kind() {
+		case ast.KindText:
+			textNode := node.(*ast.Text)
+			sb.WriteString(textNodeValue(textNode, source))
+			if textNode.SoftLineBreak() || textNode.HardLineBreak() {
+				sb.WriteString(" ")
+			}
+			return
+
+		case ast.KindString:
+			sb.WriteString(stringNodeText(node.(*ast.String)))
+			return
+
+		case ast.KindAutoLink:
+			sb.Write(node.(*ast.AutoLink).URL(source))
+			return
+
+		case ast.KindFencedCodeBlock, ast.KindCodeBlock, ast.KindHTMLBlock:
+			lines := node.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				line := lines.At(i)
+				sb.Write(line.Value(source))
+			}
+			return
+
+		case ast.KindRawHTML:
+			// Drop inline html tags
+			return
+		}
+
+		for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+			process(c)
+			if c.Type() == ast.TypeBlock {
+				sb.WriteString(" ")
+			}
+		}
+	}
+
+	process(n)
+
+	return sb.String()
+}
+
+// collapseSpaces replaces runs of whitespace with a single space.
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncateText shortens text to at most limit characters, cutting at a word
+// boundary outside any mention and marking the cut with an ellipsis.
+func truncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+	if limit == 1 {
+		// No room for text before the ellipsis
+		return "…"
+	}
+
+	var spans []textSpan
+	for _, loc := range mentionPattern.FindAllStringIndex(text, -1) {
+		spans = append(spans, textSpan{start: loc[0], end: loc[1]})
+	}
+
+	pieces := splitTrimmedText(text, limit-1, spans)
+	return pieces[0] + "…"
+}
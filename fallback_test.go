@@ -0,0 +1,90 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestConvertMarkdownTextToFallbackText(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "paragraph",
+			markdown: "This is a paragraph.",
+			want:     "This is a paragraph.",
+		},
+		{
+			name:     "heading and paragraph",
+			markdown: "# Deploy finished\n\nAll services are **healthy**.",
+			want:     "Deploy finished\nAll services are healthy.",
+		},
+		{
+			name:     "first heading only",
+			markdown: "# First\n\nText\n\n## Second\n\nMore",
+			want:     "First\nText Second More",
+		},
+		{
+			name:     "formatting stripped",
+			markdown: "Some *italic*, `code` and [a link](https://example.com).",
+			want:     "Some italic, code and a link.",
+		},
+		{
+			name:     "mentions kept",
+			markdown: "Hey <@U123> and <!here>, see <#C456|general>",
+			want:     "Hey <@U123> and <!here>, see <#C456|general>",
+		},
+		{
+			name:     "lists and quotes",
+			markdown: "- one\n- two\n  - three\n\n> quoted *text*",
+			want:     "one two three quoted text",
+		},
+		{
+			name:     "soft line breaks",
+			markdown: "line one\nline two",
+			want:     "line one line two",
+		},
+		{
+			name:     "empty",
+			markdown: "",
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ConvertMarkdownTextToFallbackText(tt.markdown)
+			if got != tt.want {
+				t.Errorf("fallback text mismatch, got=%q, want=%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertMarkdownTextToFallbackTextLength(t *testing.T) {
+	markdown := "# Report\n\n" + strings.Repeat("word <@U123456> ", 100)
+
+	got := ConvertMarkdownTextToFallbackText(markdown)
+
+	if n := utf8.RuneCountInString(got); n > MaxFallbackTextLength {
+		t.Errorf("fallback text too long, got=%d", n)
+	}
+	if !strings.HasSuffix(got, "…") {
+		t.Errorf("truncated fallback text has no ellipsis, got=%q", got)
+	}
+	if strings.Count(got, "<") != strings.Count(got, ">") {
+		t.Errorf("truncated fallback text splits a mention, got=%q", got)
+	}
+}
+
+func TestConvertMarkdownTextToFallbackTextTinyLength(t *testing.T) {
+	for _, limit := range []int{1, 2} {
+		got := NewConverter(WithMaxFallbackTextLength(limit)).ConvertMarkdownTextToFallbackText("hello world")
+		if n := utf8.RuneCountInString(got); n > limit || !strings.HasSuffix(got, "…") {
+			t.Errorf("fallback text with limit=%d, got=%q", limit, got)
+		}
+	}
+}
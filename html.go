@@ -0,0 +1,812 @@
+package util
+
+import (
+	"context"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// ConvertHTMLToBlocks converts an HTML document or fragment to a slice of slack blocks.
+func ConvertHTMLToBlocks(input string) ([]slack.Block, error) {
+	return defaultConverter.ConvertHTMLToBlocks(input)
+}
+
+// ConvertHTMLToBlocks converts an HTML document or fragment to a slice of slack blocks.
+// It gives the same blocks as converting the equivalent markdown: headings, paragraphs,
+// nested lists, code, quotes, links, images, tables, rules and emphasis are supported.
+// Scripts, styles and other embedded content are dropped with their content, and
+// unknown tags are dropped keeping their text. Links other than http, https and mailto
+// are written as their text.
+func (c *Converter) ConvertHTMLToBlocks(input string) ([]slack.Block, error) {
+	if c.maxInputSize > 0 && len(input) > c.maxInputSize {
+		return nil, &LimitError{Err: ErrInputTooLarge, Limit: c.maxInputSize}
+	}
+
+	// The HTML is built into the goldmark tree the markdown parser would produce, so it
+	// is rendered by the same code, including the list grouping and inline styles.
+	b := &htmlTreeBuilder{}
+	doc := ast.NewDocument()
+	blocks := b.newBlocks(doc, func() ast.Node { return ast.NewParagraph() })
+	blocks.children(parseHTML(input))
+	blocks.flush()
+
+	return c.convertNode(context.Background(), doc, b.source)
+}
+
+// htmlNode is an element or, when tag is empty, a text of a parsed HTML document.
+type htmlNode struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	children []*htmlNode
+}
+
+type htmlTokenKind int
+
+const (
+	htmlTextToken htmlTokenKind = iota
+	htmlStartToken
+	htmlEndToken
+)
+
+type htmlToken struct {
+	kind        htmlTokenKind
+	data        string
+	attrs       map[string]string
+	selfClosing bool
+}
+
+// htmlVoidTags are the elements that have no content or end tag.
+var htmlVoidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// htmlRawTextTags are the elements whose content is text rather than markup.
+var htmlRawTextTags = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true, "xmp": true, "iframe": true, "noembed": true, "noframes": true,
+}
+
+// htmlDroppedTags are the elements dropped with their content when converting.
+var htmlDroppedTags = map[string]bool{
+	"script": true, "style": true, "head": true, "title": true, "template": true, "noscript": true,
+	"iframe": true, "object": true, "embed": true, "svg": true, "math": true, "canvas": true,
+	"select": true, "textarea": true, "button": true, "input": true, "noembed": true, "noframes": true, "xmp": true,
+}
+
+// htmlContainerTags are block elements that only group other blocks.
+var htmlContainerTags = map[string]bool{
+	"html": true, "body": true, "div": true, "section": true, "article": true, "main": true,
+	"header": true, "footer": true, "nav": true, "aside": true, "figure": true, "figcaption": true,
+	"address": true, "center": true, "details": true, "summary": true, "dl": true, "dt": true,
+	"dd": true, "form": true, "fieldset": true, "li": true,
+}
+
+// htmlInlineTags are the elements converted to inline markdown.
+var htmlInlineTags = map[string]bool{
+	"a": true, "strong": true, "b": true, "em": true, "i": true, "del": true, "s": true,
+	"strike": true, "code": true, "kbd": true, "samp": true, "tt": true, "img": true, "br": true,
+	"span": true, "u": true, "ins": true, "small": true, "big": true, "sub": true, "sup": true,
+	"mark": true, "abbr": true, "cite": true, "q": true, "font": true, "label": true, "time": true,
+	"var": true, "dfn": true,
+}
+
+// tokenizeHTML splits input into text, start tag and end tag tokens. Comments,
+// doctypes and processing instructions are skipped, and entities are decoded.
+func tokenizeHTML(input string) []htmlToken {
+	var tokens []htmlToken
+	for i := 0; i < len(input); {
+		if input[i] != '<' {
+			end := strings.IndexByte(input[i:], '<')
+			if end < 0 {
+				end = len(input)
+			} else {
+				end += i
+			}
+			tokens = append(tokens, htmlToken{kind: htmlTextToken, data: html.UnescapeString(input[i:end])})
+			i = end
+			continue
+		}
+
+		rest := input[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return tokens
+			}
+			i += 4 + end + 3
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case len(rest) > 2 && rest[1] == '/' && isASCIILetter(rest[2]):
+			name, _ := htmlTagName(rest[2:])
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return tokens
+			}
+			tokens = append(tokens, htmlToken{kind: htmlEndToken, data: name})
+			i += end + 1
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			token, n := parseHTMLStartTag(rest)
+			if n < 0 {
+				return tokens
+			}
+			tokens = append(tokens, token)
+			i += n
+			if htmlRawTextTags[token.data] && !token.selfClosing {
+				end := indexFold(input[i:], "</"+token.data)
+				if end < 0 {
+					end = len(input) - i
+				}
+				tokens = append(tokens, htmlToken{kind: htmlTextToken, data: input[i : i+end]})
+				i += end
+			}
+		default:
+			tokens = append(tokens, htmlToken{kind: htmlTextToken, data: "<"})
+			i++
+		}
+	}
+	return tokens
+}
+
+// parseHTMLStartTag parses the start tag at the beginning of s and returns its length,
+// or -1 if it is not closed.
+func parseHTMLStartTag(s string) (htmlToken, int) {
+	name, i := htmlTagName(s[1:])
+	i++
+	token := htmlToken{kind: htmlStartToken, data: name, attrs: map[string]string{}}
+	for {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return token, -1
+		}
+		switch {
+		case s[i] == '>':
+			return token, i + 1
+		case strings.HasPrefix(s[i:], "/>"):
+			token.selfClosing = true
+			return token, i + 2
+		case s[i] == '/':
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		attr := strings.ToLower(s[start:i])
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				end := strings.IndexByte(s[i+1:], s[i])
+				if end < 0 {
+					return token, -1
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if _, ok := token.attrs[attr]; !ok && attr != "" {
+			token.attrs[attr] = html.UnescapeString(value)
+		}
+	}
+}
+
+// htmlTagName returns the lower-cased tag name at the beginning of s and its length.
+func htmlTagName(s string) (string, int) {
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	return strings.ToLower(s[:i]), i
+}
+
+// parseHTML parses input to a tree of nodes. Like browsers, it closes elements that are
+// implicitly ended, such as paragraphs and list items, and ignores stray end tags.
+func parseHTML(input string) *htmlNode {
+	root := &htmlNode{}
+	stack := []*htmlNode{root}
+
+	for _, token := range tokenizeHTML(input) {
+		switch token.kind {
+		case htmlTextToken:
+			parent := stack[len(stack)-1]
+			if n := len(parent.children); n > 0 && parent.children[n-1].tag == "" {
+				parent.children[n-1].text += token.data
+			} else {
+				parent.children = append(parent.children, &htmlNode{text: token.data})
+			}
+
+		case htmlStartToken:
+			stack = closeImpliedHTMLElements(stack, token.data)
+			n := &htmlNode{tag: token.data, attrs: token.attrs}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, n)
+			if !htmlVoidTags[token.data] && !token.selfClosing {
+				stack = append(stack, n)
+			}
+
+		case htmlEndToken:
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == token.data {
+					stack = stack[:i]
+					break
+				}
+			}
+		}
+	}
+
+	return root
+}
+
+// closeImpliedHTMLElements pops the elements that a start tag of tag ends.
+func closeImpliedHTMLElements(stack []*htmlNode, tag string) []*htmlNode {
+	var closes, scope []string
+	switch tag {
+	case "li":
+		closes, scope = []string{"li"}, []string{"ul", "ol"}
+	case "dt", "dd":
+		closes, scope = []string{"dt", "dd"}, []string{"dl"}
+	case "td", "th":
+		closes, scope = []string{"td", "th"}, []string{"tr", "table"}
+	case "tr":
+		closes, scope = []string{"tr", "td", "th"}, []string{"table", "thead", "tbody", "tfoot"}
+	case "thead", "tbody", "tfoot":
+		closes, scope = []string{"thead", "tbody", "tfoot", "tr", "td", "th"}, []string{"table"}
+	}
+
+	if isHTMLBlockTag(tag) {
+		// A block element ends the paragraph it starts in
+		i := len(stack) - 1
+		for i > 0 && htmlInlineTags[stack[i].tag] {
+			i--
+		}
+		if i > 0 && stack[i].tag == "p" {
+			stack = stack[:i]
+		}
+	}
+
+	end := len(stack)
+	for i := len(stack) - 1; i > 0; i-- {
+		if slices.Contains(scope, stack[i].tag) {
+			break
+		}
+		if slices.Contains(closes, stack[i].tag) {
+			end = i
+		}
+	}
+	return stack[:end]
+}
+
+// isHTMLBlockTag reports whether tag is an element that starts a block of its own.
+func isHTMLBlockTag(tag string) bool {
+	switch tag {
+	case "p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "pre", "blockquote", "table", "hr":
+		return true
+	}
+	return htmlContainerTags[tag]
+}
+
+// htmlTreeBuilder builds goldmark nodes from HTML. Their text is appended to source,
+// which the nodes' segments refer to.
+type htmlTreeBuilder struct {
+	source []byte
+}
+
+// htmlTextEscaper escapes the characters of HTML text that are markup in mrkdwn.
+var htmlTextEscaper = strings.NewReplacer(
+	`\`, `\\`, "&", `\&`, "<", `\<`, ">", `\>`,
+	"*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`",
+)
+
+// textNode creates a text node. Unless raw, backslashes and mrkdwn markup are escaped,
+// since the renderer unescapes the text of text nodes outside code and keeps
+// escaped punctuation literal.
+func (b *htmlTreeBuilder) textNode(s string, raw bool) *ast.Text {
+	if !raw {
+		s = htmlTextEscaper.Replace(s)
+	}
+	start := len(b.source)
+	b.source = append(b.source, s...)
+	return ast.NewTextSegment(text.NewSegment(start, len(b.source)))
+}
+
+// lines returns the segments of s split into lines.
+func (b *htmlTreeBuilder) lines(s string) *text.Segments {
+	segments := text.NewSegments()
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line == "" {
+			continue
+		}
+		start := len(b.source)
+		b.source = append(b.source, line...)
+		segments.Append(text.NewSegment(start, len(b.source)))
+	}
+	return segments
+}
+
+// htmlBlocks appends the blocks of HTML nodes to parent. Inline content outside of
+// a block element is collected into blocks created by newInline.
+type htmlBlocks struct {
+	b         *htmlTreeBuilder
+	parent    ast.Node
+	newInline func() ast.Node
+
+	inline *htmlInline
+	block  ast.Node
+}
+
+func (b *htmlTreeBuilder) newBlocks(parent ast.Node, newInline func() ast.Node) *htmlBlocks {
+	return &htmlBlocks{b: b, parent: parent, newInline: newInline}
+}
+
+func (s *htmlBlocks) children(n *htmlNode) {
+	for _, child := range n.children {
+		s.node(child)
+	}
+}
+
+// flush ends the current inline block, removing it if it has no content.
+func (s *htmlBlocks) flush() {
+	if s.block != nil && !s.inline.started {
+		s.parent.RemoveChild(s.parent, s.block)
+	}
+	s.block, s.inline = nil, nil
+}
+
+// inlineBlock returns the block that inline content is appended to.
+func (s *htmlBlocks) inlineBlock() (ast.Node, *htmlInline) {
+	if s.block == nil {
+		s.block = s.newInline()
+		s.inline = &htmlInline{b: s.b}
+		s.parent.AppendChild(s.parent, s.block)
+	}
+	return s.block, s.inline
+}
+
+func (s *htmlBlocks) node(n *htmlNode) {
+	switch {
+	case n.tag == "":
+		if strings.Trim(n.text, htmlSpace) == "" && s.block == nil {
+			return
+		}
+		block, in := s.inlineBlock()
+		in.text(block, n.text, false)
+
+	case htmlDroppedTags[n.tag]:
+
+	case htmlInlineTags[n.tag]:
+		block, in := s.inlineBlock()
+		in.node(block, n)
+
+	case len(n.tag) == 2 && n.tag[0] == 'h' && n.tag[1] >= '1' && n.tag[1] <= '6':
+		s.flush()
+		s.appendInline(ast.NewHeading(int(n.tag[1]-'0')), n)
+
+	case n.tag == "p":
+		s.flush()
+		s.appendInline(ast.NewParagraph(), n)
+
+	case n.tag == "ul" || n.tag == "ol":
+		s.flush()
+		s.list(n)
+
+	case n.tag == "pre":
+		s.flush()
+		code := ast.NewFencedCodeBlock(nil)
+		preformatted := strings.TrimPrefix(htmlNodeText(n, true), "\n")
+		if preformatted != "" && !strings.HasSuffix(preformatted, "\n") {
+			preformatted += "\n"
+		}
+		code.SetLines(s.b.lines(preformatted))
+		s.parent.AppendChild(s.parent, code)
+
+	case n.tag == "blockquote":
+		s.flush()
+		quote := ast.NewBlockquote()
+		paragraphs := s.b.newBlocks(quote, func() ast.Node { return ast.NewParagraph() })
+		paragraphs.children(n)
+		paragraphs.flush()
+		if quote.HasChildren() {
+			s.parent.AppendChild(s.parent, quote)
+		}
+
+	case n.tag == "table":
+		s.flush()
+		s.table(n)
+
+	case n.tag == "hr":
+		s.flush()
+		s.parent.AppendChild(s.parent, ast.NewThematicBreak())
+
+	case htmlContainerTags[n.tag]:
+		s.flush()
+		s.children(n)
+		s.flush()
+
+	default:
+		// Unknown tags are dropped, keeping their content
+		s.children(n)
+	}
+}
+
+// appendInline appends block with the inline content of n, unless it has none.
+func (s *htmlBlocks) appendInline(block ast.Node, n *htmlNode) {
+	in := &htmlInline{b: s.b}
+	s.parent.AppendChild(s.parent, block)
+	in.children(block, n)
+	if !in.started {
+		s.parent.RemoveChild(s.parent, block)
+	}
+}
+
+// list appends a list. Lists nested directly in a list, rather than in one of its
+// items, are nested in the item before them.
+func (s *htmlBlocks) list(n *htmlNode) {
+	list := ast.NewList('-')
+	if n.tag == "ol" {
+		list = ast.NewList('.')
+		list.Start = 1
+		if start, err := strconv.Atoi(n.attrs["start"]); err == nil {
+			list.Start = start
+		}
+	}
+	list.IsTight = true
+	s.parent.AppendChild(s.parent, list)
+
+	var item *htmlBlocks
+	for _, child := range n.children {
+		if child.tag == "" && strings.Trim(child.text, htmlSpace) == "" {
+			continue
+		}
+		if child.tag == "li" || item == nil {
+			if item != nil {
+				item.flush()
+			}
+			listItem := ast.NewListItem(2)
+			list.AppendChild(list, listItem)
+			item = s.b.newBlocks(listItem, func() ast.Node { return ast.NewTextBlock() })
+		}
+		if child.tag == "li" {
+			item.children(child)
+		} else {
+			item.node(child)
+		}
+	}
+	if item != nil {
+		item.flush()
+	}
+
+	if !list.HasChildren() {
+		s.parent.RemoveChild(s.parent, list)
+	}
+}
+
+// table appends a table. The first row is the header row if it is in a thead or
+// holds only th cells. Column alignments are taken from the first row.
+func (s *htmlBlocks) table(n *htmlNode) {
+	var rows []*htmlNode
+	header := false
+	var collect func(*htmlNode, bool)
+	collect = func(n *htmlNode, head bool) {
+		for _, child := range n.children {
+			switch child.tag {
+			case "tr":
+				if len(rows) == 0 {
+					header = head || htmlRowIsHeader(child)
+				}
+				rows = append(rows, child)
+			case "thead":
+				collect(child, true)
+			case "tbody", "tfoot":
+				collect(child, false)
+			}
+		}
+	}
+	collect(n, false)
+
+	columns := 0
+	cells := make([][]*htmlNode, len(rows))
+	for i, row := range rows {
+		for _, child := range row.children {
+			if child.tag == "td" || child.tag == "th" {
+				cells[i] = append(cells[i], child)
+			}
+		}
+		columns = max(columns, len(cells[i]))
+	}
+	if columns == 0 {
+		return
+	}
+
+	table := east.NewTable()
+	table.Alignments = make([]east.Alignment, columns)
+	for i, cell := range cells[0] {
+		table.Alignments[i] = htmlCellAlignment(cell)
+	}
+
+	for i := range rows {
+		row := east.NewTableRow(table.Alignments)
+		for j := 0; j < columns; j++ {
+			cell := east.NewTableCell()
+			cell.Alignment = table.Alignments[j]
+			if j < len(cells[i]) {
+				in := &htmlInline{b: s.b}
+				in.children(cell, cells[i][j])
+			}
+			row.AppendChild(row, cell)
+		}
+		if i == 0 && header {
+			table.AppendChild(table, east.NewTableHeader(row))
+		} else {
+			table.AppendChild(table, row)
+		}
+	}
+
+	s.parent.AppendChild(s.parent, table)
+}
+
+func htmlRowIsHeader(row *htmlNode) bool {
+	cells := 0
+	for _, child := range row.children {
+		switch child.tag {
+		case "th":
+			cells++
+		case "td":
+			return false
+		}
+	}
+	return cells > 0
+}
+
+// htmlCellAlignment returns the alignment of a cell from its align attribute or
+// its text-align style.
+func htmlCellAlignment(cell *htmlNode) east.Alignment {
+	align := strings.ToLower(cell.attrs["align"])
+	for _, decl := range strings.Split(cell.attrs["style"], ";") {
+		if property, value, ok := strings.Cut(decl, ":"); ok && strings.TrimSpace(strings.ToLower(property)) == "text-align" {
+			align = strings.TrimSpace(strings.ToLower(value))
+		}
+	}
+	switch align {
+	case "left":
+		return east.AlignLeft
+	case "center":
+		return east.AlignCenter
+	case "right":
+		return east.AlignRight
+	}
+	return east.AlignNone
+}
+
+// htmlInline appends the inline content of HTML nodes, collapsing whitespace
+// as browsers do.
+type htmlInline struct {
+	b *htmlTreeBuilder
+	// started reports whether any text was appended, space whether the text ends
+	// with a space or line break, and pending whether whitespace follows it.
+	started bool
+	space   bool
+	pending bool
+	last    *ast.Text
+}
+
+func (in *htmlInline) children(parent ast.Node, n *htmlNode) {
+	for _, child := range n.children {
+		in.node(parent, child)
+	}
+}
+
+func (in *htmlInline) node(parent ast.Node, n *htmlNode) {
+	switch n.tag {
+	case "":
+		in.text(parent, n.text, false)
+
+	case "strong", "b":
+		in.appendStyled(parent, ast.NewEmphasis(2), n)
+
+	case "em", "i":
+		in.appendStyled(parent, ast.NewEmphasis(1), n)
+
+	case "del", "s", "strike":
+		in.appendStyled(parent, east.NewStrikethrough(), n)
+
+	case "code", "kbd", "samp", "tt":
+		in.flushSpace(parent, n)
+		code := ast.NewCodeSpan()
+		parent.AppendChild(parent, code)
+		in.text(code, htmlNodeText(n, false), true)
+		if !code.HasChildren() {
+			parent.RemoveChild(parent, code)
+		}
+
+	case "a":
+		href := strings.TrimSpace(n.attrs["href"])
+		if !isAllowedHTMLLink(href) {
+			in.children(parent, n)
+			return
+		}
+		label := strings.Join(htmlFields(htmlNodeText(n, false)), " ")
+		if label == "" {
+			label = href
+		}
+		in.flushSpace(parent, n)
+		link := ast.NewLink()
+		link.Destination = []byte(href)
+		parent.AppendChild(parent, link)
+		in.text(link, label, false)
+		in.pending = htmlEndsWithSpace(htmlNodeText(n, false))
+
+	case "img":
+		in.text(parent, n.attrs["alt"], false)
+
+	case "br":
+		if in.last == nil || in.last.HardLineBreak() {
+			in.last = in.b.textNode("", false)
+			parent.AppendChild(parent, in.last)
+		}
+		in.last.SetHardLineBreak(true)
+		in.started, in.space, in.pending = true, true, false
+
+	default:
+		if !htmlDroppedTags[n.tag] {
+			in.children(parent, n)
+		}
+	}
+}
+
+// appendStyled appends the emphasis or strikethrough node with the content of n.
+func (in *htmlInline) appendStyled(parent, styled ast.Node, n *htmlNode) {
+	in.flushSpace(parent, n)
+	parent.AppendChild(parent, styled)
+	in.children(styled, n)
+	if !styled.HasChildren() {
+		parent.RemoveChild(parent, styled)
+	}
+}
+
+// flushSpace appends the space before an element outside of it, since Slack does not
+// style text whose markers are next to spaces.
+func (in *htmlInline) flushSpace(parent ast.Node, n *htmlNode) {
+	if (in.pending || htmlStartsWithSpace(htmlNodeText(n, false))) && in.started && !in.space {
+		in.last = in.b.textNode(" ", false)
+		parent.AppendChild(parent, in.last)
+		in.space, in.pending = true, false
+	}
+}
+
+// text appends s with its whitespace collapsed.
+func (in *htmlInline) text(parent ast.Node, s string, raw bool) {
+	if htmlStartsWithSpace(s) {
+		in.pending = true
+	}
+	words := htmlFields(s)
+	if len(words) == 0 {
+		return
+	}
+	t := strings.Join(words, " ")
+	if in.pending && in.started && !in.space {
+		t = " " + t
+	}
+	in.last = in.b.textNode(t, raw)
+	parent.AppendChild(parent, in.last)
+	in.started, in.space, in.pending = true, false, htmlEndsWithSpace(s)
+}
+
+// htmlNodeText returns the text of n. Line breaks and block elements start new lines,
+// and unless preformatted, other whitespace is turned into spaces.
+func htmlNodeText(n *htmlNode, preformatted bool) string {
+	var sb strings.Builder
+	var walk func(*htmlNode)
+	walk = func(n *htmlNode) {
+		switch {
+		case n.tag == "":
+			if preformatted {
+				sb.WriteString(n.text)
+			} else {
+				sb.WriteString(strings.Map(func(r rune) rune {
+					if r < 0x80 && isHTMLSpace(byte(r)) {
+						return ' '
+					}
+					return r
+				}, n.text))
+			}
+		case n.tag == "br":
+			sb.WriteByte('\n')
+		case n.tag == "img":
+			sb.WriteString(n.attrs["alt"])
+		case htmlDroppedTags[n.tag]:
+		default:
+			block := !htmlInlineTags[n.tag] && n.tag != ""
+			if block && !preformatted {
+				sb.WriteByte('\n')
+			}
+			for _, child := range n.children {
+				walk(child)
+			}
+			if block && !preformatted {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	for _, child := range n.children {
+		walk(child)
+	}
+	return sb.String()
+}
+
+// isAllowedHTMLLink reports whether href is an absolute link Slack can open.
+func isAllowedHTMLLink(href string) bool {
+	scheme, _, ok := strings.Cut(href, ":")
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+const htmlSpace = " \t\n\f\r"
+
+func isHTMLSpace(ch byte) bool {
+	return strings.IndexByte(htmlSpace, ch) >= 0
+}
+
+// htmlFields splits s around runs of HTML whitespace, which unlike unicode.IsSpace
+// does not include non-breaking spaces.
+func htmlFields(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r < 0x80 && isHTMLSpace(byte(r))
+	})
+}
+
+func htmlStartsWithSpace(s string) bool {
+	return s != "" && isHTMLSpace(s[0])
+}
+
+func htmlEndsWithSpace(s string) bool {
+	return s != "" && isHTMLSpace(s[len(s)-1])
+}
+
+func isASCIILetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+// indexFold is strings.Index ignoring the ASCII case of substr.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
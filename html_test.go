@@ -0,0 +1,235 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestConvertHTMLToBlocks(t *testing.T) {
+	// HTML has strikethrough and tables, which markdown only has with GFM
+	gfm := NewConverter(WithExtensions(extension.GFM))
+
+	tests := []struct {
+		name     string
+		html     string
+		markdown string
+	}{
+		{
+			name:     "headings and paragraphs",
+			html:     "<h1>Title</h1>\n<p>First  paragraph\nwith <strong>bold</strong>, <em>italic</em> and <del>gone</del>.</p><p>Second</p>",
+			markdown: "# Title\n\nFirst paragraph with **bold**, *italic* and ~~gone~~.\n\nSecond",
+		},
+		{
+			name:     "bold italic",
+			html:     "<p><b>bold <i>both</i></b> <i>italic</i></p>",
+			markdown: "**bold *both*** *italic*",
+		},
+		{
+			name:     "space before emphasis",
+			html:     "<p>a<b> bold </b>b</p>",
+			markdown: "a **bold** b",
+		},
+		{
+			name:     "nested lists",
+			html:     "<ul><li>one<ul><li>nested</li></ul></li><li>two</li></ul><ol start=\"3\"><li>three<li>four</ol>",
+			markdown: "- one\n  - nested\n- two\n\n3. three\n4. four",
+		},
+		{
+			name:     "list nested directly in a list",
+			html:     "<ul><li>one</li><ul><li>nested</li></ul></ul>",
+			markdown: "- one\n  - nested",
+		},
+		{
+			name:     "code",
+			html:     "<p>Run <code>go test</code></p><pre><code class=\"language-go\">func main() {\n\tprintln(\"&lt;hi&gt;\")\n}\n</code></pre>",
+			markdown: "Run `go test`\n\n```go\nfunc main() {\n\tprintln(\"<hi>\")\n}\n```",
+		},
+		{
+			name:     "blockquote",
+			html:     "<blockquote><p>quoted</p><p>more</p></blockquote>",
+			markdown: "> quoted\n> more",
+		},
+		{
+			name:     "blockquote with inline markup",
+			html:     "<blockquote><p>see <a href=\"https://e.com\">docs</a> and <strong>bold</strong></p>loose <em>text</em></blockquote>",
+			markdown: "> see [docs](https://e.com) and **bold**\n>\n> loose *text*",
+		},
+		{
+			name:     "links and images",
+			html:     "<p>See <a href=\"https://example.com\">the <b>docs</b></a> and <img src=\"x.png\" alt=\"diagram\"></p>",
+			markdown: "See [the docs](https://example.com) and ![diagram](x.png)",
+		},
+		{
+			name:     "unsafe link",
+			html:     "<p><a href=\"javascript:alert(1)\">click</a></p>",
+			markdown: "click",
+		},
+		{
+			name:     "line break and rule",
+			html:     "<p>one<br>two</p><hr><p>three</p>",
+			markdown: "one\\\ntwo\n\n---\n\nthree",
+		},
+		{
+			name:     "table",
+			html:     "<table><thead><tr><th>Name</th><th align=\"right\">Count</th></tr></thead><tbody><tr><td>a</td><td style=\"text-align: right\">1</td></tr><tr><td>b</td></tr></tbody></table>",
+			markdown: "| Name | Count |\n| --- | ---: |\n| a | 1 |\n| b | |",
+		},
+		{
+			name:     "sanitized",
+			html:     "<!DOCTYPE html><html><head><title>t</title><style>p { color: red }</style></head><body><script>alert('</p>')</script><div><custom-tag>kept <span>text</span></custom-tag></div><!-- comment --><iframe src=\"x\">frame</iframe></body></html>",
+			markdown: "kept text",
+		},
+		{
+			name:     "entities and backslashes",
+			html:     "<p>a &amp; b &lt; c C:\\path\\*</p>",
+			markdown: "a \\& b \\< c C:\\\\path\\\\\\*",
+		},
+		{
+			name:     "entities that look like mrkdwn",
+			html:     "<p>Reply &lt;!channel&gt; now, see &lt;https://evil.example|bank.com&gt;</p>",
+			markdown: "Reply \\<!channel\\> now, see \\<https://evil.example|bank.com\\>",
+		},
+		{
+			name:     "mrkdwn markers in text",
+			html:     "<p>*x* _y_ ~z~ `w`</p><ul><li>*item*</li></ul>",
+			markdown: "\\*x\\* \\_y\\_ \\~z\\~ \\`w\\`\n\n- \\*item\\*",
+		},
+		{
+			name:     "text outside paragraphs",
+			html:     "loose <b>text</b><p>para</p>tail",
+			markdown: "loose **text**\n\npara\n\ntail",
+		},
+		{
+			name:     "empty",
+			html:     "<p> </p><ul></ul>",
+			markdown: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertHTMLToBlocks(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertHTMLToBlocks() error = %v", err)
+			}
+			want, err := gfm.ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("ConvertHTMLToBlocks() = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLToBlocksEscapesMrkdwn(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "special mention",
+			html: "<p>Reply &lt;!channel&gt; now</p>",
+			want: "Reply &lt;!channel&gt; now",
+		},
+		{
+			name: "disguised link",
+			html: "<p>&lt;https://evil.example|bank.com&gt;</p>",
+			want: "&lt;https://evil.example|bank.com&gt;",
+		},
+		{
+			name: "emphasis markers",
+			html: "<p>*x* and 2 * 3</p>",
+			want: "\u200b*\u200bx\u200b*\u200b and 2 * 3",
+		},
+		{
+			name: "link label",
+			html: "<p><a href=\"https://example.com\">a &gt; b</a></p>",
+			want: "<https://example.com|a &gt; b>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertHTMLToBlocks(tt.html)
+			if err != nil {
+				t.Fatalf("ConvertHTMLToBlocks() error = %v", err)
+			}
+			if text := got[0].(*slack.SectionBlock).Text.Text; text != tt.want {
+				t.Errorf("section text = %q, want %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertHTMLToBlocksLimits(t *testing.T) {
+	c := NewConverter(WithMaxInputSize(10))
+	var limitErr *LimitError
+	if _, err := c.ConvertHTMLToBlocks("<p>" + strings.Repeat("a", 20) + "</p>"); !errors.As(err, &limitErr) || limitErr.Err != ErrInputTooLarge {
+		t.Errorf("ConvertHTMLToBlocks() error = %v, want ErrInputTooLarge", err)
+	}
+
+	c = NewConverter(WithMaxNestingDepth(5))
+	deep := strings.Repeat("<ul><li>x", 10)
+	if _, err := c.ConvertHTMLToBlocks(deep); !errors.As(err, &limitErr) || limitErr.Err != ErrNestingTooDeep {
+		t.Errorf("ConvertHTMLToBlocks() error = %v, want ErrNestingTooDeep", err)
+	}
+}
+
+func TestParseHTML(t *testing.T) {
+	tests := []struct {
+		html string
+		want string
+	}{
+		{html: "<p>a<p>b", want: "p(a) p(b)"},
+		{html: "<ul><li>a<li>b</ul>", want: "ul(li(a) li(b))"},
+		{html: "<table><tr><td>a<td>b<tr><td>c</table>", want: "table(tr(td(a) td(b)) tr(td(c)))"},
+		{html: "<p>a <b>b</p>c", want: "p(a  b(b)) c"},
+		{html: "a</div>b", want: "ab"},
+		{html: "<A HREF=x Title='y'>a</A>", want: "a[href=x title=y](a)"},
+		{html: "x < y <br/> z", want: "x < y  br  z"},
+		{html: "<p>unclosed <b", want: "p(unclosed )"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.html, func(t *testing.T) {
+			if got := dumpHTML(parseHTML(tt.html).children); got != tt.want {
+				t.Errorf("parseHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func dumpHTML(nodes []*htmlNode) string {
+	var parts []string
+	for _, n := range nodes {
+		if n.tag == "" {
+			parts = append(parts, n.text)
+			continue
+		}
+		s := n.tag
+		if len(n.attrs) > 0 {
+			var attrs []string
+			for _, name := range []string{"href", "title"} {
+				if value, ok := n.attrs[name]; ok {
+					attrs = append(attrs, name+"="+value)
+				}
+			}
+			s += "[" + strings.Join(attrs, " ") + "]"
+		}
+		if len(n.children) > 0 {
+			s += "(" + dumpHTML(n.children) + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,315 @@
+package util
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	// MaxSectionTextLength is the maximum number of characters in a section block's text.
+	MaxSectionTextLength = 3000
+	// MaxHeaderTextLength is the maximum number of characters in a header block's text.
+	MaxHeaderTextLength = 150
+	// MaxPreformattedTextLength is the maximum number of characters put in a single
+	// preformatted rich_text block before the code is continued in the next block.
+	MaxPreformattedTextLength = 3000
+)
+
+// textSpan is a byte range [start, end) of a text that must not be split,
+// such as a mrkdwn link or emphasis.
+type textSpan struct {
+	start int
+	end   int
+}
+
+// cut kinds in order of preference
+const (
+	cutLine = iota
+	cutSentence
+	cutWord
+	cutGrapheme
+	cutKinds
+)
+
+// splitText splits text into pieces of at most limit characters.
+// Pieces are cut at line, sentence, word or grapheme boundaries, in that order of
+// preference, and never inside one of the protected spans unless a span alone is
+// longer than the limit. Joining the pieces gives back the original text, except
+// that an emphasis or code span cut that way is closed before the cut and reopened
+// after it, so that each piece keeps balanced markers.
+func splitText(text string, limit int, spans []textSpan) []string {
+	return splitTextFunc(text, limit, spans, false)
+}
+
+// splitTrimmedText is like splitText but trims the whitespace around each cut,
+// so the whitespace does not count towards the limit of the next piece.
+func splitTrimmedText(text string, limit int, spans []textSpan) []string {
+	return splitTextFunc(text, limit, spans, true)
+}
+
+func splitTextFunc(text string, limit int, spans []textSpan, trim bool) []string {
+	var pieces []string
+	limit = max(limit, 1)
+
+	for utf8.RuneCountInString(text) > limit {
+		cut := findCut(text, limit, spans)
+		if n := len(cutEmphasisSpans(text, cut, spans)); n > 0 && n < limit {
+			// Leave room for the markers that close the cut spans
+			cut = findCut(text, limit-n, spans)
+		}
+		if cut == 0 {
+			// Always make progress, even if the first character is cut
+			_, cut = utf8.DecodeRuneInString(text)
+		}
+		reopened := cutEmphasisSpans(text, cut, spans)
+
+		var open, closing string
+		for _, i := range reopened {
+			marker := text[spans[i].start : spans[i].start+1]
+			open += marker
+			closing = marker + closing
+		}
+
+		head, tail := text[:cut], text[cut:]
+		if trim {
+			head = strings.TrimRightFunc(head, unicode.IsSpace)
+			tail = strings.TrimLeftFunc(tail, unicode.IsSpace)
+		}
+		if head != "" {
+			pieces = append(pieces, head+closing)
+		}
+		shift := len(text) - len(tail) - len(open)
+		text = open + tail
+
+		// Shift the remaining spans to the new start of text, where the cut
+		// spans start at their reopening markers
+		var rest []textSpan
+		for i, s := range spans {
+			if s.end <= cut {
+				continue
+			}
+			start := max(s.start-shift, 0)
+			if j := slices.Index(reopened, i); j >= 0 {
+				start = j
+			}
+			rest = append(rest, textSpan{start: start, end: s.end - shift})
+		}
+		spans = rest
+	}
+
+	return append(pieces, text)
+}
+
+// cutEmphasisSpans returns the indexes of the emphasis and code spans that a cut at
+// pos falls inside, outermost first. Spans cut right after their opening marker are
+// left out, since closing them there would leave an empty emphasis.
+func cutEmphasisSpans(text string, pos int, spans []textSpan) []int {
+	var cut []int
+	for i, s := range spans {
+		if pos <= s.start+1 || pos >= s.end {
+			continue
+		}
+		marker := text[s.start]
+		if strings.IndexByte("*_~`", marker) < 0 || text[s.end-1] != marker {
+			continue
+		}
+		cut = append(cut, i)
+	}
+	slices.SortStableFunc(cut, func(a, b int) int { return spans[a].start - spans[b].start })
+	return cut
+}
+
+// findCut returns the byte offset at which text should be cut so that the head
+// has at most limit characters. text must be longer than limit characters.
+func findCut(text string, limit int, spans []textSpan) int {
+	// Byte offset just after the limit-th character
+	end := 0
+	for i := 0; i < limit; i++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	var best [cutKinds]int
+	prev, _ := utf8.DecodeRuneInString(text)
+	for pos := utf8.RuneLen(prev); pos <= end && pos < len(text); {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if isGraphemeBoundary(prev, r) && !insideSpan(pos, spans) {
+			best[cutGrapheme] = pos
+			// Words and sentences are cut before the following whitespace so
+			// that the head fits even when the whitespace does not
+			if !unicode.IsSpace(prev) && unicode.IsSpace(r) {
+				best[cutWord] = pos
+				if isSentenceEnd(prev) {
+					best[cutSentence] = pos
+				}
+			}
+			if prev == '\n' {
+				best[cutLine] = pos
+			}
+		}
+		prev = r
+		pos += size
+	}
+
+	// Prefer a stronger boundary as long as it keeps the head reasonably full
+	for kind := 0; kind < cutKinds; kind++ {
+		if best[kind] > 0 && best[kind] >= end/2 {
+			return best[kind]
+		}
+	}
+	for kind := 0; kind < cutKinds; kind++ {
+		if best[kind] > 0 {
+			return best[kind]
+		}
+	}
+
+	// A single protected span is longer than the limit; cut it at the best
+	// boundary inside the span, or at the limit
+	if spans != nil {
+		return findCut(text, limit, nil)
+	}
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return end
+}
+
+// insideSpan reports whether pos falls strictly inside one of the spans.
+func insideSpan(pos int, spans []textSpan) bool {
+	for _, s := range spans {
+		if s.start < pos && pos < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// isSentenceEnd reports whether r is punctuation that ends a sentence.
+func isSentenceEnd(r rune) bool {
+	switch r {
+	case '.', '!', '?', '。', '！', '？':
+		return true
+	}
+	return false
+}
+
+// isGraphemeBoundary reports whether a user-perceived character boundary lies
+// between prev and next. It covers combining marks, variation selectors, emoji
+// modifiers and zero width joiner sequences.
+func isGraphemeBoundary(prev, next rune) bool {
+	if prev == '\r' && next == '\n' {
+		return false
+	}
+	if prev == '\u200d' {
+		return false
+	}
+	switch {
+	case next == '\u200d',
+		next >= '\ufe00' && next <= '\ufe0f',
+		next >= '\U0001f3fb' && next <= '\U0001f3ff',
+		next >= '\U000e0020' && next <= '\U000e007f',
+		unicode.Is(unicode.Mn, next),
+		unicode.Is(unicode.Me, next),
+		unicode.Is(unicode.Mc, next):
+		return false
+	}
+	return true
+}
+
+// newSectionBlocks creates section blocks for a mrkdwn text, splitting it over
+// several blocks when it is longer than the maximum section text length.
+func (c *Converter) newSectionBlocks(mrkdwn string, spans []textSpan) []slack.Block {
+	var blocks []slack.Block
+	for _, piece := range splitTrimmedText(mrkdwn, c.maxSectionTextLength, spans) {
+		blocks = append(blocks, &slack.SectionBlock{
+			Type: slack.MBTSection,
+			Text: &slack.TextBlockObject{
+				Type: slack.MarkdownType,
+				Text: piece,
+			},
+		})
+	}
+	return blocks
+}
+
+// newHeadingBlocks creates the blocks for a heading in the converter's heading style.
+func (c *Converter) newHeadingBlocks(heading *ast.Heading, source []byte) []slack.Block {
+	if c.headingStyle == HeadingStyleBold {
+		mrkdwn, spans := c.renderMrkdwn(heading, source)
+		// The heading is emphasised as a whole, so it is only split where it overflows
+		if utf8.RuneCountInString(mrkdwn)+2 <= c.maxSectionTextLength {
+			mrkdwn = "*" + mrkdwn + "*"
+			spans = []textSpan{{start: 0, end: len(mrkdwn)}}
+		}
+		return c.newSectionBlocks(mrkdwn, spans)
+	}
+
+	return c.newHeaderBlocks(extractPlainText(heading, source))
+}
+
+// newHeaderBlocks creates a header block for a heading text. Text beyond the
+// maximum header text length is moved into plain text sections following the header.
+func (c *Converter) newHeaderBlocks(text string) []slack.Block {
+	pieces := splitTrimmedText(text, c.maxHeaderTextLength, nil)
+
+	blocks := []slack.Block{
+		&slack.HeaderBlock{
+			Type: slack.MBTHeader,
+			Text: c.newPlainText(pieces[0]),
+		},
+	}
+
+	if len(pieces) == 1 {
+		return blocks
+	}
+
+	overflow := strings.Join(pieces[1:], " ")
+	for _, piece := range splitTrimmedText(overflow, c.maxSectionTextLength, nil) {
+		blocks = append(blocks, &slack.SectionBlock{
+			Type: slack.MBTSection,
+			Text: c.newPlainText(piece),
+		})
+	}
+
+	return blocks
+}
+
+// newPlainText creates a plain_text object with the converter's emoji setting.
+func (c *Converter) newPlainText(text string) *slack.TextBlockObject {
+	emojiEnabled := c.emoji
+	return &slack.TextBlockObject{
+		Type:  slack.PlainTextType,
+		Text:  text,
+		Emoji: &emojiEnabled,
+	}
+}
+
+// newPreformattedBlocks creates rich_text blocks holding a code text, continuing
+// the code in another block after the maximum preformatted text length.
+func (c *Converter) newPreformattedBlocks(code string) []slack.Block {
+	var blocks []slack.Block
+	for _, piece := range splitText(code, c.maxPreformattedTextLength, nil) {
+		blocks = append(blocks, &slack.RichTextBlock{
+			Type: slack.MBTRichText,
+			Elements: []slack.RichTextElement{
+				&slack.RichTextPreformatted{
+					RichTextSection: slack.RichTextSection{
+						Type: slack.RTEPreformatted,
+						Elements: []slack.RichTextSectionElement{
+							&slack.RichTextSectionTextElement{
+								Type: slack.RTSEText,
+								Text: piece,
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+	return blocks
+}
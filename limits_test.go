@@ -0,0 +1,269 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		spans []textSpan
+		trim  bool
+		want  []string
+	}{
+		{
+			name:  "under limit",
+			text:  "short",
+			limit: 10,
+			want:  []string{"short"},
+		},
+		{
+			name:  "line boundary",
+			text:  "first line\nsecond line",
+			limit: 15,
+			want:  []string{"first line\n", "second line"},
+		},
+		{
+			name:  "sentence boundary",
+			text:  "One two. Three four five",
+			limit: 16,
+			want:  []string{"One two.", " Three four five"},
+		},
+		{
+			name:  "word boundary",
+			text:  "alpha beta gamma",
+			limit: 12,
+			want:  []string{"alpha beta", " gamma"},
+		},
+		{
+			name:  "trimmed",
+			text:  "alpha  beta gamma",
+			limit: 11,
+			trim:  true,
+			want:  []string{"alpha  beta", "gamma"},
+		},
+		{
+			name:  "word that fits without its trailing space",
+			text:  "alpha beta gamma",
+			limit: 10,
+			trim:  true,
+			want:  []string{"alpha beta", "gamma"},
+		},
+		{
+			name:  "grapheme boundary",
+			text:  "abcdefghij",
+			limit: 4,
+			want:  []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:  "keeps combining marks",
+			text:  "abcdéfg",
+			limit: 5,
+			want:  []string{"abcd", "éfg"},
+		},
+		{
+			name:  "keeps zwj sequences",
+			text:  "ab👩‍💻cd",
+			limit: 4,
+			want:  []string{"ab", "👩‍💻c", "d"},
+		},
+		{
+			name:  "protected span longer than limit",
+			text:  "aa *bold text* bb",
+			limit: 10,
+			spans: []textSpan{{start: 3, end: 14}},
+			trim:  true,
+			want:  []string{"aa", "*bold*", "*text* bb"},
+		},
+		{
+			name:  "nested spans longer than limit",
+			text:  "*_one two three_*",
+			limit: 10,
+			spans: []textSpan{{start: 0, end: 17}, {start: 1, end: 16}},
+			trim:  true,
+			want:  []string{"*_one_*", "*_two_*", "*_three_*"},
+		},
+		{
+			name:  "code span longer than limit",
+			text:  "`abcdefghijkl`",
+			limit: 8,
+			spans: []textSpan{{start: 0, end: 14}},
+			want:  []string{"`abcdef`", "`ghijkl`"},
+		},
+		{
+			name:  "link longer than limit",
+			text:  "<https://example.com|site>",
+			limit: 20,
+			spans: []textSpan{{start: 0, end: 26}},
+			want:  []string{"<https://example.com", "|site>"},
+		},
+		{
+			name:  "zero limit",
+			text:  "abc",
+			limit: 0,
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name:  "cut after protected span",
+			text:  "aa *bold text* bb",
+			limit: 15,
+			spans: []textSpan{{start: 3, end: 14}},
+			want:  []string{"aa *bold text*", " bb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitTextFunc(tt.text, tt.limit, tt.spans, tt.trim)
+
+			if !tt.trim && !strings.ContainsAny(tt.text, "*_~`") && strings.Join(got, "") != tt.text {
+				t.Errorf("pieces do not join back to the text, got=%q", got)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("piece count mismatch, got=%q, want=%q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("piece mismatch at index=%d, got=%q, want=%q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConvertMarkdownTextToBlocksTextLimits(t *testing.T) {
+	t.Run("long paragraph", func(t *testing.T) {
+		sentence := "This sentence has a [link](https://example.com) and **bold words** in it. "
+		markdown := strings.Repeat(sentence, 100)
+
+		blocks, err := ConvertMarkdownTextToBlocks(markdown)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(blocks) < 2 {
+			t.Fatalf("expected the paragraph to be split, got %d block(s)", len(blocks))
+		}
+
+		for i, block := range blocks {
+			section, ok := block.(*slack.SectionBlock)
+			if !ok {
+				t.Fatalf("block at index=%d is not a section, got=%T", i, block)
+			}
+			text := section.Text.Text
+			if n := utf8.RuneCountInString(text); n > MaxSectionTextLength {
+				t.Errorf("section text too long at index=%d, got=%d", i, n)
+			}
+			if strings.Count(text, "<") != strings.Count(text, ">") {
+				t.Errorf("section text splits a link at index=%d", i)
+			}
+			if strings.Count(text, "*")%2 != 0 {
+				t.Errorf("section text splits an emphasis at index=%d", i)
+			}
+			if !strings.HasSuffix(text, ".") {
+				t.Errorf("section text not cut at a sentence at index=%d, got=%q", i, text[len(text)-10:])
+			}
+		}
+	})
+
+	t.Run("emphasis longer than a section", func(t *testing.T) {
+		c := NewConverter(WithMaxSectionTextLength(20))
+		blocks, err := c.ConvertMarkdownTextToBlocks("**" + strings.TrimSpace(strings.Repeat("bold words ", 6)) + "** and `" + strings.TrimSpace(strings.Repeat("code ", 6)) + "`")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(blocks) < 4 {
+			t.Fatalf("expected the paragraph to be split, got %d block(s)", len(blocks))
+		}
+
+		for i, block := range blocks {
+			text := block.(*slack.SectionBlock).Text.Text
+			if n := utf8.RuneCountInString(text); n > 20 {
+				t.Errorf("section text too long at index=%d, got=%d", i, n)
+			}
+			if text != "and" && (text[0] != text[len(text)-1] || !strings.ContainsRune("*`", rune(text[0]))) {
+				t.Errorf("section text does not close and reopen the span at index=%d, got=%q", i, text)
+			}
+		}
+	})
+
+	t.Run("long heading", func(t *testing.T) {
+		markdown := "# " + strings.Repeat("word ", 40)
+
+		blocks, err := ConvertMarkdownTextToBlocks(markdown)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(blocks) != 2 {
+			t.Fatalf("block count mismatch, got=%v, want=%v", len(blocks), 2)
+		}
+
+		header, ok := blocks[0].(*slack.HeaderBlock)
+		if !ok {
+			t.Fatalf("first block is not a header, got=%T", blocks[0])
+		}
+		if n := utf8.RuneCountInString(header.Text.Text); n > MaxHeaderTextLength {
+			t.Errorf("header text too long, got=%d", n)
+		}
+
+		section, ok := blocks[1].(*slack.SectionBlock)
+		if !ok {
+			t.Fatalf("second block is not a section, got=%T", blocks[1])
+		}
+		if section.Text.Type != slack.PlainTextType {
+			t.Errorf("overflow text type mismatch, got=%v, want=%v", section.Text.Type, slack.PlainTextType)
+		}
+		if got := header.Text.Text + " " + section.Text.Text; got != strings.TrimSpace(strings.Repeat("word ", 40)) {
+			t.Errorf("heading text lost while splitting, got=%q", got)
+		}
+	})
+
+	t.Run("heading with inline markup", func(t *testing.T) {
+		blocks, err := ConvertMarkdownTextToBlocks("# **Bold** and `code` [title](https://example.com)")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		header, ok := blocks[0].(*slack.HeaderBlock)
+		if !ok {
+			t.Fatalf("first block is not a header, got=%T", blocks[0])
+		}
+		if got, want := header.Text.Text, "Bold and code title"; got != want {
+			t.Errorf("header text mismatch, got=%q, want=%q", got, want)
+		}
+	})
+
+	t.Run("long code block", func(t *testing.T) {
+		code := strings.Repeat("fmt.Println(\"hello, world\")\n", 300)
+		markdown := "```go\n" + code + "```"
+
+		blocks, err := ConvertMarkdownTextToBlocks(markdown)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(blocks) < 2 {
+			t.Fatalf("expected the code block to be split, got %d block(s)", len(blocks))
+		}
+
+		var joined string
+		for i, block := range blocks {
+			richText := block.(*slack.RichTextBlock)
+			pre := richText.Elements[0].(*slack.RichTextPreformatted)
+			text := pre.Elements[0].(*slack.RichTextSectionTextElement).Text
+			if n := utf8.RuneCountInString(text); n > MaxPreformattedTextLength {
+				t.Errorf("code text too long at index=%d, got=%d", i, n)
+			}
+			if !strings.HasSuffix(text, "\n") {
+				t.Errorf("code not cut at a line at index=%d", i)
+			}
+			joined += text
+		}
+		if joined != code {
+			t.Errorf("code lost while splitting")
+		}
+	})
+}
@@ -1,174 +1,327 @@
 package util
 
 import (
+	"context"
 	"fmt"
+	"html"
+	"slices"
+	"strings"
 
 	"github.com/slack-go/slack"
-	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
+	east "github.com/yuin/goldmark/extension/ast"
 	"github.com/yuin/goldmark/text"
+	gutil "github.com/yuin/goldmark/util"
 )
 
-var md = goldmark.New()
-
 // ConvertMarkdownTextToBlocks converts a markdown text to a slice of slack blocks.
 func ConvertMarkdownTextToBlocks(markdown string) ([]slack.Block, error) {
-	source := []byte(markdown)
-	doc := md.Parser().Parse(text.NewReader(source))
-	blocks := []slack.Block{}
+	return defaultConverter.ConvertMarkdownTextToBlocks(markdown)
+}
+
+// ConvertMarkdownTextToBlocks converts a markdown text to a slice of slack blocks.
+func (c *Converter) ConvertMarkdownTextToBlocks(markdown string) ([]slack.Block, error) {
+	return c.convert(context.Background(), []byte(markdown))
+}
+
+// ConvertNodeToBlocks converts a parsed markdown node, usually an ast.Document, to a
+// slice of slack blocks. source is the markdown the node was parsed from.
+func (c *Converter) ConvertNodeToBlocks(n ast.Node, source []byte) ([]slack.Block, error) {
+	return c.convertNode(context.Background(), n, source)
+}
+
+// renderBlocks walks a node and its descendants and renders them to slack blocks.
+// When runHooks is set, the hooks are called for the blocks of every rendered node
+// and the maximum output block count is enforced.
+func (c *Converter) renderBlocks(ctx context.Context, root ast.Node, source []byte, runHooks bool) ([]slack.Block, error) {
+	var blocks []slack.Block
 
-	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+	err := ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
 		if !entering {
 			return ast.WalkContinue, nil
 		}
+		if err := ctx.Err(); err != nil {
+			return ast.WalkStop, err
+		}
 
-		switch n.Kind() {
-		case ast.KindHeading:
-			heading := n.(*ast.Heading)
-			var text string
-			lines := heading.Lines()
-			for i := 0; i < lines.Len(); i++ {
-				line := lines.At(i)
-				text += string(line.Value(source))
+		rendered, status, err := c.renderBlock(ctx, n, source)
+		if err != nil {
+			return ast.WalkStop, err
+		}
+		if len(rendered) > 0 && runHooks {
+			for _, hook := range c.hooks {
+				rendered = hook(n, rendered)
 			}
-			emojiEnabled := true
-			blocks = append(blocks, &slack.HeaderBlock{
-				Type: slack.MBTHeader,
-				Text: &slack.TextBlockObject{
-					Type:  slack.PlainTextType,
-					Text:  text,
-					Emoji: &emojiEnabled,
-				},
-			})
-			return ast.WalkSkipChildren, nil
+		}
+		blocks = append(blocks, rendered...)
+		if runHooks && c.maxOutputBlocks > 0 && len(blocks) > c.maxOutputBlocks {
+			return ast.WalkStop, &LimitError{Err: ErrTooManyBlocks, Limit: c.maxOutputBlocks}
+		}
+		return status, nil
+	})
 
-		case ast.KindParagraph:
-			para := n.(*ast.Paragraph)
-			var paraText string
-			lines := para.Lines()
-			for i := 0; i < lines.Len(); i++ {
-				line := lines.At(i)
-				paraText += string(line.Value(source))
-			}
-			mrkdwn := convertInlineMarkdownToMrkdwn(paraText)
-			blocks = append(blocks, &slack.SectionBlock{
-				Type: slack.MBTSection,
-				Text: &slack.TextBlockObject{
-					Type: slack.MarkdownType,
-					Text: mrkdwn,
-				},
-			})
-			return ast.WalkSkipChildren, nil
-
-		case ast.KindList:
-			list := n.(*ast.List)
-			// Collect all list items with their indent levels (handles nested lists)
-			richTextElements := collectListItems(list, source, 0)
-			blocks = append(blocks, &slack.RichTextBlock{
-				Type:     slack.MBTRichText,
-				Elements: richTextElements,
-			})
-			return ast.WalkSkipChildren, nil
+	if err != nil {
+		return nil, err
+	}
 
-		case ast.KindFencedCodeBlock:
-			code := n.(*ast.FencedCodeBlock)
-			var codeText string
-			lines := code.Lines()
-			for i := 0; i < lines.Len(); i++ {
-				line := lines.At(i)
-				codeText += string(line.Value(source))
-			}
+	return blocks, nil
+}
 
-			blocks = append(blocks, &slack.RichTextBlock{
-				Type: slack.MBTRichText,
-				Elements: []slack.RichTextElement{
-					&slack.RichTextPreformatted{
-						RichTextSection: slack.RichTextSection{
-							Type: slack.RTEPreformatted,
-							Elements: []slack.RichTextSectionElement{
-								&slack.RichTextSectionTextElement{
-									Type: slack.RTSEText,
-									Text: codeText,
-								},
-							},
-						},
-					},
-				},
-			})
-			return ast.WalkSkipChildren, nil
+// renderBlock renders a markdown node to slack blocks with the renderer registered for
+// its kind, or with the default renderer. It returns ast.WalkSkipChildren when the node
+// has been rendered including its children.
+func (c *Converter) renderBlock(ctx context.Context, n ast.Node, source []byte) ([]slack.Block, ast.WalkStatus, error) {
+	if r, ok := c.blockRenderers[n.Kind()]; ok {
+		blocks, err := r(c.newRenderContext(ctx, source), n)
+		return blocks, ast.WalkSkipChildren, err
+	}
 
-		case ast.KindCodeSpan:
-			code := n.(*ast.CodeSpan)
-			var text string
-			lines := code.Lines()
-			for i := 0; i < lines.Len(); i++ {
-				line := lines.At(i)
-				text += string(line.Value(source))
-			}
-			elements := []slack.RichTextSectionElement{
-				&slack.RichTextSectionTextElement{
-					Type: slack.RTSEText,
-					Text: text,
-					Style: &slack.RichTextSectionTextStyle{
-						Code: true,
-					},
+	if code, ok := n.(*ast.FencedCodeBlock); ok && c.codeUploader != nil {
+		blocks, uploaded, err := c.renderUploadedCode(ctx, code, source)
+		if uploaded || err != nil {
+			return blocks, ast.WalkSkipChildren, err
+		}
+	}
+
+	blocks, status := c.renderDefaultBlock(n, source)
+	return blocks, status, nil
+}
+
+// renderDefaultBlock renders a markdown node to slack blocks with the built-in rendering
+// of its kind. It returns ast.WalkSkipChildren when the node has been rendered including
+// its children.
+func (c *Converter) renderDefaultBlock(n ast.Node, source []byte) ([]slack.Block, ast.WalkStatus) {
+	var blocks []slack.Block
+
+	switch n.Kind() {
+	case ast.KindHeading:
+		heading := n.(*ast.Heading)
+		blocks = append(blocks, c.newHeadingBlocks(heading, source)...)
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindParagraph:
+		para := n.(*ast.Paragraph)
+		mrkdwn, spans := c.renderMrkdwn(para, source)
+		blocks = append(blocks, c.newSectionBlocks(mrkdwn, spans)...)
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindList:
+		list := n.(*ast.List)
+		// Collect all list items with their indent levels (handles nested lists)
+		richTextElements := c.collectListItems(list, source, 0)
+		blocks = append(blocks, &slack.RichTextBlock{
+			Type:     slack.MBTRichText,
+			Elements: richTextElements,
+		})
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindFencedCodeBlock:
+		code := n.(*ast.FencedCodeBlock)
+		var codeText string
+		lines := code.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			codeText += string(line.Value(source))
+		}
+		blocks = append(blocks, c.newPreformattedBlocks(codeText)...)
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindCodeSpan:
+		code := n.(*ast.CodeSpan)
+		var text string
+		lines := code.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			text += string(line.Value(source))
+		}
+		elements := []slack.RichTextSectionElement{
+			&slack.RichTextSectionTextElement{
+				Type: slack.RTSEText,
+				Text: text,
+				Style: &slack.RichTextSectionTextStyle{
+					Code: true,
 				},
-			}
-			blocks = append(blocks, &slack.RichTextBlock{
-				Type: slack.MBTRichText,
-				Elements: []slack.RichTextElement{
-					&slack.RichTextSection{
-						Type:     slack.RTESection,
-						Elements: elements,
-					},
+			},
+		}
+		blocks = append(blocks, &slack.RichTextBlock{
+			Type: slack.MBTRichText,
+			Elements: []slack.RichTextElement{
+				&slack.RichTextSection{
+					Type:     slack.RTESection,
+					Elements: elements,
 				},
-			})
-			return ast.WalkSkipChildren, nil
-
-		case ast.KindBlockquote:
-			quote := n.(*ast.Blockquote)
-			var quoteText string
-			for child := quote.FirstChild(); child != nil; child = child.NextSibling() {
-				if child.Kind() == ast.KindParagraph {
-					lines := child.Lines()
-					for i := 0; i < lines.Len(); i++ {
-						line := lines.At(i)
-						quoteText += string(line.Value(source))
-					}
+			},
+		})
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindBlockquote:
+		quote := n.(*ast.Blockquote)
+		var elements []slack.RichTextSectionElement
+		for child := quote.FirstChild(); child != nil; child = child.NextSibling() {
+			if child.Kind() == ast.KindParagraph {
+				// Paragraphs of the quote go on lines of their own
+				if len(elements) > 0 {
+					elements = append(elements, &slack.RichTextSectionTextElement{
+						Type: slack.RTSEText,
+						Text: "\n",
+					})
 				}
+				elements = append(elements, c.parseInlineElements(child, source)...)
 			}
-			blocks = append(blocks, &slack.RichTextBlock{
-				Type: slack.MBTRichText,
-				Elements: []slack.RichTextElement{
-					&slack.RichTextQuote{
-						Type: slack.RTEQuote,
-						Elements: []slack.RichTextSectionElement{
-							&slack.RichTextSectionTextElement{
-								Type: slack.RTSEText,
-								Text: quoteText,
-							},
-						},
-					},
+		}
+		if len(elements) == 0 {
+			elements = []slack.RichTextSectionElement{
+				&slack.RichTextSectionTextElement{Type: slack.RTSEText},
+			}
+		}
+		blocks = append(blocks, &slack.RichTextBlock{
+			Type: slack.MBTRichText,
+			Elements: []slack.RichTextElement{
+				&slack.RichTextQuote{
+					Type: slack.RTEQuote,
+					// Text split over several nodes, such as by the parser's
+					// extensions or by HTML, is joined
+					Elements: mergeTextElements(elements),
 				},
-			})
-			return ast.WalkSkipChildren, nil
+			},
+		})
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindLink:
+		link := n.(*ast.Link)
+		var text string
+		for c := link.FirstChild(); c != nil; c = c.NextSibling() {
+			if c.Kind() == ast.KindText {
+				textNode := c.(*ast.Text)
+				text += string(textNode.Segment.Value(source))
+			}
+		}
+		elements := []slack.RichTextSectionElement{
+			&slack.RichTextSectionTextElement{
+				Type: slack.RTSEText,
+				Text: text,
+			},
+		}
+		blocks = append(blocks, &slack.RichTextBlock{
+			Type: slack.MBTRichText,
+			Elements: []slack.RichTextElement{
+				&slack.RichTextSection{
+					Type:     slack.RTESection,
+					Elements: elements,
+				},
+			},
+		})
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindCodeBlock:
+		var codeText string
+		lines := n.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			line := lines.At(i)
+			codeText += string(line.Value(source))
+		}
+		blocks = append(blocks, c.newPreformattedBlocks(codeText)...)
+		return blocks, ast.WalkSkipChildren
 
-		case ast.KindLink:
-			link := n.(*ast.Link)
-			var text string
-			for c := link.FirstChild(); c != nil; c = c.NextSibling() {
-				if c.Kind() == ast.KindText {
-					textNode := c.(*ast.Text)
-					text += string(textNode.Segment.Value(source))
-				}
+	case ast.KindThematicBreak:
+		blocks = append(blocks, &slack.DividerBlock{
+			Type: slack.MBTDivider,
+		})
+		return blocks, ast.WalkSkipChildren
+
+	case ast.KindHTMLBlock:
+		// Raw html has no Block Kit counterpart
+		return blocks, ast.WalkSkipChildren
+
+	case east.KindTable:
+		table := n.(*east.Table)
+		blocks = append(blocks, c.newTableBlocks(table, source)...)
+		return blocks, ast.WalkSkipChildren
+	}
+
+	// Fall back to text for nodes of extensions that hold inline content or lines
+	if n.Type() == ast.TypeBlock && n.Kind() != ast.KindDocument {
+		var mrkdwn string
+		var spans []textSpan
+		if child := n.FirstChild(); child != nil && child.Type() == ast.TypeInline {
+			mrkdwn, spans = c.renderMrkdwn(n, source)
+		} else if child == nil {
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				line := lines.At(i)
+				mrkdwn += string(line.Value(source))
 			}
-			elements := []slack.RichTextSectionElement{
-				&slack.RichTextSectionTextElement{
+		} else {
+			return blocks, ast.WalkContinue
+		}
+		if strings.TrimSpace(mrkdwn) != "" {
+			blocks = append(blocks, c.newSectionBlocks(mrkdwn, spans)...)
+		}
+		return blocks, ast.WalkSkipChildren
+	}
+
+	return blocks, ast.WalkContinue
+}
+
+// newTableBlocks creates table blocks for a GFM table. The cells of the header row are
+// bold. Columns beyond MaxTableColumns are dropped, and rows beyond MaxTableRows are
+// continued in further tables that repeat the header row.
+func (c *Converter) newTableBlocks(table *east.Table, source []byte) []slack.Block {
+	block := c.newTableBlock(table, source)
+	if len(block.Rows) <= MaxTableRows {
+		return []slack.Block{block}
+	}
+
+	header, body := block.Rows[:1], block.Rows[1:]
+	var blocks []slack.Block
+	for len(body) > 0 {
+		n := min(len(body), MaxTableRows-1)
+		blocks = append(blocks, &slack.TableBlock{
+			Type:           slack.MBTTable,
+			ColumnSettings: block.ColumnSettings,
+			Rows:           append(slices.Clip(header), body[:n]...),
+		})
+		body = body[n:]
+	}
+	return blocks
+}
+
+// newTableBlock creates a table block with all the rows of a GFM table.
+func (c *Converter) newTableBlock(table *east.Table, source []byte) *slack.TableBlock {
+	block := &slack.TableBlock{
+		Type: slack.MBTTable,
+	}
+
+	for i, align := range table.Alignments {
+		if i == MaxTableColumns {
+			break
+		}
+		setting := slack.ColumnSetting{Align: slack.ColumnAlignmentLeft}
+		switch align {
+		case east.AlignCenter:
+			setting.Align = slack.ColumnAlignmentCenter
+		case east.AlignRight:
+			setting.Align = slack.ColumnAlignmentRight
+		}
+		block.ColumnSettings = append(block.ColumnSettings, setting)
+	}
+
+	for row := table.FirstChild(); row != nil; row = row.NextSibling() {
+		isHeader := row.Kind() == east.KindTableHeader
+		var cells []*slack.RichTextBlock
+		for cell := row.FirstChild(); cell != nil && len(cells) < MaxTableColumns; cell = cell.NextSibling() {
+			var elements []slack.RichTextSectionElement
+			for child := cell.FirstChild(); child != nil; child = child.NextSibling() {
+				elements = append(elements, c.parseStyledInlineElements(child, source, isHeader, false)...)
+			}
+			if len(elements) == 0 {
+				// Slack rejects empty rich text sections
+				elements = append(elements, &slack.RichTextSectionTextElement{
 					Type: slack.RTSEText,
-					Text: text,
-				},
+					Text: " ",
+				})
 			}
-			blocks = append(blocks, &slack.RichTextBlock{
+			cells = append(cells, &slack.RichTextBlock{
 				Type: slack.MBTRichText,
 				Elements: []slack.RichTextElement{
 					&slack.RichTextSection{
@@ -177,17 +330,11 @@ func ConvertMarkdownTextToBlocks(markdown string) ([]slack.Block, error) {
 					},
 				},
 			})
-			return ast.WalkSkipChildren, nil
 		}
-
-		return ast.WalkContinue, nil
-	})
-
-	if err != nil {
-		return nil, err
+		block.Rows = append(block.Rows, cells)
 	}
 
-	return blocks, nil
+	return block
 }
 
 // listItemWithIndent represents a list item with its indentation level and style
@@ -200,7 +347,7 @@ type listItemWithIndent struct {
 // collectListItems recursively collects all list items from a list and its nested sublists,
 // returning them as a flat slice of RichTextList elements with proper indent levels.
 // This enables Slack's Block Kit to render nested lists correctly.
-func collectListItems(list *ast.List, source []byte, indent int) []slack.RichTextElement {
+func (c *Converter) collectListItems(list *ast.List, source []byte, indent int) []slack.RichTextElement {
 	var items []listItemWithIndent
 	style := getListStyle(list)
 
@@ -214,11 +361,11 @@ func collectListItems(list *ast.List, source []byte, indent int) []slack.RichTex
 			if child.Kind() == ast.KindList {
 				// Recursively collect nested list items
 				nestedList := child.(*ast.List)
-				nestedElements := collectListItemsFlat(nestedList, source, indent+1)
+				nestedElements := c.collectListItemsFlat(nestedList, source, indent+1)
 				items = append(items, nestedElements...)
 			} else {
 				// This is the content of the list item (paragraph, text, etc.)
-				elements := parseInlineElements(child, source)
+				elements := c.parseInlineElements(child, source)
 				if len(elements) > 0 {
 					section := &slack.RichTextSection{
 						Type:     slack.RTESection,
@@ -239,7 +386,7 @@ func collectListItems(list *ast.List, source []byte, indent int) []slack.RichTex
 }
 
 // collectListItemsFlat is like collectListItems but returns listItemWithIndent for internal use
-func collectListItemsFlat(list *ast.List, source []byte, indent int) []listItemWithIndent {
+func (c *Converter) collectListItemsFlat(list *ast.List, source []byte, indent int) []listItemWithIndent {
 	var items []listItemWithIndent
 	style := getListStyle(list)
 
@@ -251,10 +398,10 @@ func collectListItemsFlat(list *ast.List, source []byte, indent int) []listItemW
 		for child := listItem.FirstChild(); child != nil; child = child.NextSibling() {
 			if child.Kind() == ast.KindList {
 				nestedList := child.(*ast.List)
-				nestedElements := collectListItemsFlat(nestedList, source, indent+1)
+				nestedElements := c.collectListItemsFlat(nestedList, source, indent+1)
 				items = append(items, nestedElements...)
 			} else {
-				elements := parseInlineElements(child, source)
+				elements := c.parseInlineElements(child, source)
 				if len(elements) > 0 {
 					section := &slack.RichTextSection{
 						Type:     slack.RTESection,
@@ -325,9 +472,30 @@ func getListStyle(list *ast.List) slack.RichTextListElementType {
 	return slack.RTEListBullet
 }
 
-func parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionElement {
+func (c *Converter) parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionElement {
+	return c.parseStyledInlineElements(n, source, false, false)
+}
+
+// parseStyledInlineElements is like parseInlineElements but renders n inside an enclosing emphasis.
+func (c *Converter) parseStyledInlineElements(n ast.Node, source []byte, bold, italic bool) []slack.RichTextSectionElement {
+	softBreak := c.softLineBreak()
+	renderers := c.inlineRenderers
+	ctx := c.newRenderContext(context.Background(), source)
 	var elements []slack.RichTextSectionElement
 	var currentText string
+	var strike int
+
+	// textStyle returns the style of text inside the current emphasis and strikethrough
+	textStyle := func(isBold, isItalic bool) *slack.RichTextSectionTextStyle {
+		style := getTextStyle(isBold, isItalic)
+		if strike > 0 {
+			if style == nil {
+				style = &slack.RichTextSectionTextStyle{}
+			}
+			style.Strike = true
+		}
+		return style
+	}
 
 	var process func(ast.Node, bool, bool)
 	process = func(node ast.Node, isBold, isItalic bool) {
@@ -335,10 +503,20 @@ func parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionEleme
 			return
 		}
 
+		if r, ok := renderers[node.Kind()]; ok && node.Type() == ast.TypeInline {
+			elements = append(elements, r(ctx, node, textStyle(isBold, isItalic))...)
+			return
+		}
+
 		switch node.Kind() {
 		case ast.KindText:
 			textNode := node.(*ast.Text)
-			text := string(textNode.Segment.Value(source))
+			text := textNodeValue(textNode, source)
+			if textNode.HardLineBreak() {
+				text += "\n"
+			} else if textNode.SoftLineBreak() {
+				text += softBreak
+			}
 			if currentText != "" {
 				elements = append(elements, &slack.RichTextSectionTextElement{
 					Type: slack.RTSEText,
@@ -347,13 +525,44 @@ func parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionEleme
 				currentText = ""
 			}
 
-			style := getTextStyle(isBold, isItalic)
+			style := textStyle(isBold, isItalic)
 			elements = append(elements, &slack.RichTextSectionTextElement{
 				Type:  slack.RTSEText,
 				Text:  text,
 				Style: style,
 			})
 
+		case ast.KindString:
+			elements = append(elements, &slack.RichTextSectionTextElement{
+				Type:  slack.RTSEText,
+				Text:  stringNodeText(node.(*ast.String)),
+				Style: textStyle(isBold, isItalic),
+			})
+
+		case ast.KindRawHTML:
+			// Drop inline html tags
+
+		case ast.KindAutoLink:
+			autoLink := node.(*ast.AutoLink)
+			elements = append(elements, &slack.RichTextSectionLinkElement{
+				Type: slack.RTSELink,
+				Text: string(autoLink.Label(source)),
+				URL:  string(autoLink.URL(source)),
+			})
+
+		case east.KindStrikethrough:
+			strike++
+			for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+				process(c, isBold, isItalic)
+			}
+			strike--
+
+		case east.KindTaskCheckBox:
+			elements = append(elements, &slack.RichTextSectionTextElement{
+				Type: slack.RTSEText,
+				Text: taskCheckBoxText(node.(*east.TaskCheckBox)),
+			})
+
 		case ast.KindEmphasis:
 			emp := node.(*ast.Emphasis)
 			newBold := isBold || emp.Level == 2
@@ -368,7 +577,7 @@ func parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionEleme
 			for c := link.FirstChild(); c != nil; c = c.NextSibling() {
 				if c.Kind() == ast.KindText {
 					textNode := c.(*ast.Text)
-					text += string(textNode.Segment.Value(source))
+					text += textNodeValue(textNode, source)
 				}
 			}
 			elements = append(elements, &slack.RichTextSectionLinkElement{
@@ -407,7 +616,7 @@ func parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionEleme
 		}
 	}
 
-	process(n, false, false)
+	process(n, bold, italic)
 
 	if currentText != "" {
 		elements = append(elements, &slack.RichTextSectionTextElement{
@@ -419,6 +628,61 @@ func parseInlineElements(n ast.Node, source []byte) []slack.RichTextSectionEleme
 	return elements
 }
 
+// stringNodeText returns the text of a string node. Extensions such as the typographer
+// produce html entities in code strings, which are decoded since Slack only
+// understands a few of them.
+func stringNodeText(n *ast.String) string {
+	if n.IsCode() {
+		return html.UnescapeString(string(n.Value))
+	}
+	return string(n.Value)
+}
+
+// textNodeValue returns the text of a text node with backslash escapes removed.
+func textNodeValue(n *ast.Text, source []byte) string {
+	return string(gutil.UnescapePunctuations(n.Segment.Value(source)))
+}
+
+// mrkdwnTextNodeValue returns the text of a text node as mrkdwn. Punctuation escaped
+// with a backslash stays literal: &, < and > become entities, and emphasis and code
+// markers that could open or close a style are wrapped in zero width spaces so that
+// Slack does not style the text.
+func mrkdwnTextNodeValue(n *ast.Text, source []byte) string {
+	value := n.Segment.Value(source)
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) || !gutil.IsPunct(value[i+1]) {
+			sb.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch c := value[i]; c {
+		case '&', '<', '>':
+			sb.WriteString(mrkdwnEscaper.Replace(string(c)))
+		case '*', '_', '~', '`':
+			// A marker between spaces styles nothing. The source is looked at
+			// rather than value, since the text may continue in other nodes
+			pos := n.Segment.Start + i
+			if (pos < 2 || gutil.IsSpace(source[pos-2])) && (pos+1 == len(source) || gutil.IsSpace(source[pos+1])) {
+				sb.WriteByte(c)
+			} else {
+				sb.WriteString("\u200b" + string(c) + "\u200b")
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// taskCheckBoxText returns the text a GFM task list check box is rendered as.
+func taskCheckBoxText(box *east.TaskCheckBox) string {
+	if box.IsChecked {
+		return "☑ "
+	}
+	return "☐ "
+}
+
 func getTextStyle(isBold, isItalic bool) *slack.RichTextSectionTextStyle {
 	if !isBold && !isItalic {
 		return nil
@@ -431,8 +695,27 @@ func getTextStyle(isBold, isItalic bool) *slack.RichTextSectionTextStyle {
 
 func convertInlineMarkdownToMrkdwn(markdown string) string {
 	source := []byte(markdown)
-	doc := md.Parser().Parse(text.NewReader(source))
+	doc := defaultConverter.markdown.Parser().Parse(text.NewReader(source))
+	var result string
+
+	for c := doc.FirstChild(); c != nil; c = c.NextSibling() {
+		if c.Kind() == ast.KindParagraph {
+			mrkdwn, _ := defaultConverter.renderMrkdwn(c, source)
+			result += mrkdwn
+		}
+	}
+
+	return result
+}
+
+// renderMrkdwn renders the inline children of a node as mrkdwn. It also returns the
+// spans of links, emphasis and code in the result, which must not be split.
+func (c *Converter) renderMrkdwn(n ast.Node, source []byte) (string, []textSpan) {
+	softBreak := c.softLineBreak()
+	renderers := c.inlineRenderers
+	ctx := c.newRenderContext(context.Background(), source)
 	var result string
+	var spans []textSpan
 
 	var processNode func(ast.Node)
 	processNode = func(n ast.Node) {
@@ -440,13 +723,26 @@ func convertInlineMarkdownToMrkdwn(markdown string) string {
 			return
 		}
 
+		if r, ok := renderers[n.Kind()]; ok && n.Type() == ast.TypeInline {
+			start := len(result)
+			result += richTextElementsToMrkdwn(r(ctx, n, nil))
+			spans = append(spans, textSpan{start: start, end: len(result)})
+			return
+		}
+
 		switch n.Kind() {
 		case ast.KindText:
 			textNode := n.(*ast.Text)
-			result += string(textNode.Segment.Value(source))
+			result += mrkdwnTextNodeValue(textNode, source)
+			if textNode.HardLineBreak() {
+				result += "\n"
+			} else if textNode.SoftLineBreak() {
+				result += softBreak
+			}
 
 		case ast.KindEmphasis:
 			emp := n.(*ast.Emphasis)
+			start := len(result)
 			switch emp.Level {
 			case 2:
 				result += "*"
@@ -461,18 +757,48 @@ func convertInlineMarkdownToMrkdwn(markdown string) string {
 				}
 				result += "_"
 			}
+			spans = append(spans, textSpan{start: start, end: len(result)})
+			return
+
+		case ast.KindString:
+			result += stringNodeText(n.(*ast.String))
+
+		case ast.KindRawHTML:
+			// Drop inline html tags
+			return
+
+		case ast.KindAutoLink:
+			autoLink := n.(*ast.AutoLink)
+			start := len(result)
+			result += "<" + string(autoLink.URL(source)) + ">"
+			spans = append(spans, textSpan{start: start, end: len(result)})
+			return
+
+		case east.KindStrikethrough:
+			start := len(result)
+			result += "~"
+			for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+				processNode(c)
+			}
+			result += "~"
+			spans = append(spans, textSpan{start: start, end: len(result)})
 			return
 
+		case east.KindTaskCheckBox:
+			result += taskCheckBoxText(n.(*east.TaskCheckBox))
+
 		case ast.KindLink:
 			link := n.(*ast.Link)
 			var text string
 			for c := link.FirstChild(); c != nil; c = c.NextSibling() {
 				if c.Kind() == ast.KindText {
 					textNode := c.(*ast.Text)
-					text += string(textNode.Segment.Value(source))
+					text += mrkdwnTextNodeValue(textNode, source)
 				}
 			}
+			start := len(result)
 			result += fmt.Sprintf("<%s|%s>", string(link.Destination), text)
+			spans = append(spans, textSpan{start: start, end: len(result)})
 			return
 
 		case ast.KindCodeSpan:
@@ -483,7 +809,9 @@ func convertInlineMarkdownToMrkdwn(markdown string) string {
 					text += string(textNode.Segment.Value(source))
 				}
 			}
+			start := len(result)
 			result += "`" + text + "`"
+			spans = append(spans, textSpan{start: start, end: len(result)})
 			return
 
 		default:
@@ -493,13 +821,17 @@ func convertInlineMarkdownToMrkdwn(markdown string) string {
 		}
 	}
 
-	for c := doc.FirstChild(); c != nil; c = c.NextSibling() {
-		if c.Kind() == ast.KindParagraph {
-			for child := c.FirstChild(); child != nil; child = child.NextSibling() {
-				processNode(child)
-			}
-		}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		processNode(child)
 	}
 
-	return result
+	return result, spans
+}
+
+// softLineBreak returns the text a soft line break is rendered as.
+func (c *Converter) softLineBreak() string {
+	if c.lineBreaks == LineBreakSpace {
+		return " "
+	}
+	return "\n"
 }
@@ -668,3 +668,68 @@ func TestConvertMarkdownTextToBlocks(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertMarkdownTextToBlocksEscapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "escaped emphasis",
+			markdown: `\*not bold\* and \_not italic\_`,
+			want:     "​*​not bold​*​ and ​_​not italic​_​",
+		},
+		{
+			name:     "escaped marker between spaces",
+			markdown: `2 \* 3 \_ 4`,
+			want:     "2 * 3 _ 4",
+		},
+		{
+			name:     "escaped strike and code",
+			markdown: "\\~not struck\\~ \\`not code\\`",
+			want:     "​~​not struck​~​ ​`​not code​`​",
+		},
+		{
+			name:     "escaped entities",
+			markdown: `\<!channel\> \& co`,
+			want:     "&lt;!channel&gt; &amp; co",
+		},
+		{
+			name:     "other escaped punctuation",
+			markdown: `\[not a link\] \# 1`,
+			want:     "[not a link] # 1",
+		},
+		{
+			name:     "escaped link label",
+			markdown: `[\*docs\*](https://example.com)`,
+			want:     "<https://example.com|​*​docs​*​>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("block count mismatch, got=%v, want=%v", len(got), 1)
+			}
+			if text := got[0].(*slack.SectionBlock).Text.Text; text != tt.want {
+				t.Errorf("section text mismatch, got=%q, want=%q", text, tt.want)
+			}
+		})
+	}
+
+	t.Run("rich text keeps the literal text", func(t *testing.T) {
+		got, err := ConvertMarkdownTextToBlocks(`- \*item\* \<b\>`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		section := got[0].(*slack.RichTextBlock).Elements[0].(*slack.RichTextList).Elements[0].(*slack.RichTextSection)
+		if text := section.Elements[0].(*slack.RichTextSectionTextElement).Text; text != "*item* <b>" {
+			t.Errorf("list text mismatch, got=%q, want=%q", text, "*item* <b>")
+		}
+	})
+}
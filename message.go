@@ -0,0 +1,168 @@
+package util
+
+import (
+	"sync"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+)
+
+// MentionLookup resolves the names of mentioned users, channels and user groups.
+// The methods report false for IDs they cannot resolve.
+type MentionLookup interface {
+	UserName(userID string) (string, bool)
+	ChannelName(channelID string) (string, bool)
+	UserGroupName(userGroupID string) (string, bool)
+}
+
+// MentionNames is a MentionLookup backed by maps from IDs to names.
+type MentionNames struct {
+	Users      map[string]string
+	Channels   map[string]string
+	UserGroups map[string]string
+}
+
+// UserName implements MentionLookup.
+func (m MentionNames) UserName(userID string) (string, bool) {
+	name, ok := m.Users[userID]
+	return name, ok
+}
+
+// ChannelName implements MentionLookup.
+func (m MentionNames) ChannelName(channelID string) (string, bool) {
+	name, ok := m.Channels[channelID]
+	return name, ok
+}
+
+// UserGroupName implements MentionLookup.
+func (m MentionNames) UserGroupName(userGroupID string) (string, bool) {
+	name, ok := m.UserGroups[userGroupID]
+	return name, ok
+}
+
+// MarkdownMentionLookup writes mentions as "@name" and "#name" with the names resolved
+// by lookup. Mentions that cannot be resolved are written in Slack's syntax.
+func MarkdownMentionLookup(lookup MentionLookup) MarkdownOption {
+	return func(c *markdownConfig) {
+		user, channel, userGroup := c.user, c.channel, c.userGroup
+		c.user = func(id string) string {
+			if name, ok := lookup.UserName(id); ok {
+				return "@" + escapeInlineMarkdown(name)
+			}
+			return user(id)
+		}
+		c.channel = func(id string) string {
+			if name, ok := lookup.ChannelName(id); ok {
+				return "#" + escapeInlineMarkdown(name)
+			}
+			return channel(id)
+		}
+		c.userGroup = func(id string) string {
+			if name, ok := lookup.UserGroupName(id); ok {
+				return "@" + escapeInlineMarkdown(name)
+			}
+			return userGroup(id)
+		}
+	}
+}
+
+// ConvertMessageToMarkdown converts a message to markdown. Messages with blocks, such as
+// the rich_text blocks of messages users type, are converted from their blocks, which keep
+// lists, code and quotes. Other messages are converted from their mrkdwn text.
+func ConvertMessageToMarkdown(msg *slack.Msg, opts ...MarkdownOption) (string, error) {
+	if len(msg.Blocks.BlockSet) > 0 {
+		return ConvertBlocksToMarkdown(msg.Blocks.BlockSet, opts...)
+	}
+	return ConvertMrkdwnToMarkdown(msg.Text, opts...), nil
+}
+
+// ConvertMessageEventToMarkdown converts the message of a message event to markdown,
+// as ConvertMessageToMarkdown does. For message_changed events it is the changed message.
+func ConvertMessageEventToMarkdown(event *slackevents.MessageEvent, opts ...MarkdownOption) (string, error) {
+	if event.Message != nil {
+		return ConvertMessageToMarkdown(event.Message, opts...)
+	}
+	return ConvertMrkdwnToMarkdown(event.Text, opts...), nil
+}
+
+// clientMentionLookup resolves names with the Slack Web API and caches them.
+type clientMentionLookup struct {
+	client *slack.Client
+
+	mu         sync.Mutex
+	users      map[string]string
+	channels   map[string]string
+	userGroups map[string]string
+}
+
+// NewClientMentionLookup creates a MentionLookup that resolves names with the Web API
+// methods users.info, conversations.info and usergroups.list, and caches them. Users
+// are named by their display name, falling back to their real name.
+func NewClientMentionLookup(client *slack.Client) MentionLookup {
+	return &clientMentionLookup{
+		client:   client,
+		users:    map[string]string{},
+		channels: map[string]string{},
+	}
+}
+
+func (l *clientMentionLookup) UserName(userID string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if name, ok := l.users[userID]; ok {
+		return name, name != ""
+	}
+	user, err := l.client.GetUserInfo(userID)
+	if err != nil {
+		return "", false
+	}
+	name := userDisplayName(user)
+	l.users[userID] = name
+	return name, name != ""
+}
+
+func (l *clientMentionLookup) ChannelName(channelID string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if name, ok := l.channels[channelID]; ok {
+		return name, name != ""
+	}
+	channel, err := l.client.GetConversationInfo(&slack.GetConversationInfoInput{ChannelID: channelID})
+	if err != nil {
+		return "", false
+	}
+	l.channels[channelID] = channel.Name
+	return channel.Name, channel.Name != ""
+}
+
+func (l *clientMentionLookup) UserGroupName(userGroupID string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.userGroups == nil {
+		groups, err := l.client.GetUserGroups()
+		if err != nil {
+			return "", false
+		}
+		l.userGroups = map[string]string{}
+		for _, group := range groups {
+			l.userGroups[group.ID] = group.Handle
+		}
+	}
+	name, ok := l.userGroups[userGroupID]
+	return name, ok && name != ""
+}
+
+// userDisplayName names user by their display name, falling back to their real name
+// and then to their username.
+func userDisplayName(user *slack.User) string {
+	if user.Profile.DisplayName != "" {
+		return user.Profile.DisplayName
+	}
+	if user.RealName != "" {
+		return user.RealName
+	}
+	return user.Name
+}
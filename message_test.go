@@ -0,0 +1,113 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slackevents"
+	"github.com/slack-go/slack/slacktest"
+)
+
+const richTextEventJSON = `{
+	"type": "message",
+	"channel": "C1",
+	"user": "U1",
+	"text": "fallback text",
+	"ts": "1700000000.000100",
+	"blocks": [{
+		"type": "rich_text",
+		"block_id": "abc",
+		"elements": [
+			{"type": "rich_text_section", "elements": [
+				{"type": "user", "user_id": "U2"},
+				{"type": "text", "text": " please check "},
+				{"type": "channel", "channel_id": "C9"},
+				{"type": "text", "text": ":\n"}
+			]},
+			{"type": "rich_text_list", "style": "bullet", "indent": 0, "elements": [
+				{"type": "rich_text_section", "elements": [{"type": "text", "text": "the ", "style": {"bold": true}}, {"type": "text", "text": "logs", "style": {"bold": true}}]},
+				{"type": "rich_text_section", "elements": [{"type": "link", "url": "https://example.com", "text": "dashboard"}]}
+			]},
+			{"type": "rich_text_list", "style": "ordered", "indent": 1, "elements": [
+				{"type": "rich_text_section", "elements": [{"type": "text", "text": "nested"}]}
+			]},
+			{"type": "rich_text_preformatted", "elements": [{"type": "text", "text": "kubectl get pods"}]},
+			{"type": "rich_text_quote", "elements": [{"type": "text", "text": "it worked "}, {"type": "usergroup", "usergroup_id": "S1"}, {"type": "text", "text": " "}, {"type": "emoji", "name": "tada", "unicode": "1f389"}]}
+		]
+	}]
+}`
+
+func TestConvertMessageEventToMarkdown(t *testing.T) {
+	var event slackevents.MessageEvent
+	if err := json.Unmarshal([]byte(richTextEventJSON), &event); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lookup := MentionNames{
+		Users:      map[string]string{"U2": "alice_b"},
+		Channels:   map[string]string{"C9": "ops"},
+		UserGroups: map[string]string{},
+	}
+	got, err := ConvertMessageEventToMarkdown(&event, MarkdownMentionLookup(lookup))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "@alice_b please check #ops:\n\n" +
+		"- **the logs**\n- [dashboard](https://example.com)\n  1. nested\n\n" +
+		"```\nkubectl get pods\n```\n\n" +
+		"> it worked <!subteam^S1> :tada:"
+	if got != want {
+		t.Errorf("markdown mismatch\ngot:  %q\nwant: %q", got, want)
+	}
+}
+
+func TestConvertMessageToMarkdownText(t *testing.T) {
+	msg := &slack.Msg{Text: "*Deploy* done for <@U1>"}
+
+	got, err := ConvertMessageToMarkdown(msg, MarkdownMentionLookup(MentionNames{Users: map[string]string{"U1": "bob"}}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "**Deploy** done for @bob"; got != want {
+		t.Errorf("markdown mismatch, got=%q, want=%q", got, want)
+	}
+}
+
+func TestClientMentionLookup(t *testing.T) {
+	var userCalls atomic.Int32
+	s := slacktest.NewTestServer(func(c slacktest.Customize) {
+		c.Handle("/users.info", func(w http.ResponseWriter, r *http.Request) {
+			userCalls.Add(1)
+			_, _ = w.Write([]byte(`{"ok": true, "user": {"id": "U1", "name": "carol", "real_name": "Carol C", "profile": {"display_name": ""}}}`))
+		})
+		c.Handle("/usergroups.list", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"ok": true, "usergroups": [{"id": "S1", "handle": "oncall"}]}`))
+		})
+	})
+	s.Start()
+	defer s.Stop()
+
+	lookup := NewClientMentionLookup(slack.New("xoxb-test", slack.OptionAPIURL(s.GetAPIURL())))
+
+	for i := 0; i < 2; i++ {
+		if name, ok := lookup.UserName("U1"); !ok || name != "Carol C" {
+			t.Errorf("user name mismatch, got=%q, ok=%v", name, ok)
+		}
+	}
+	if n := userCalls.Load(); n != 1 {
+		t.Errorf("user lookups are not cached, calls=%d", n)
+	}
+	if name, ok := lookup.ChannelName("C123"); !ok || name != "123" {
+		t.Errorf("channel name mismatch, got=%q, ok=%v", name, ok)
+	}
+	if name, ok := lookup.UserGroupName("S1"); !ok || name != "oncall" {
+		t.Errorf("user group name mismatch, got=%q, ok=%v", name, ok)
+	}
+	if _, ok := lookup.UserGroupName("S2"); ok {
+		t.Errorf("unknown user group resolved")
+	}
+}
@@ -0,0 +1,362 @@
+package util
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MrkdwnKind is the kind of a MrkdwnNode.
+type MrkdwnKind int
+
+const (
+	// MrkdwnDocument is the root of parsed mrkdwn. Its children are paragraphs, quotes and code blocks.
+	MrkdwnDocument MrkdwnKind = iota
+	// MrkdwnParagraph holds inline nodes.
+	MrkdwnParagraph
+	// MrkdwnQuote holds the paragraphs of consecutive ">" lines, or of the text after ">>>".
+	MrkdwnQuote
+	// MrkdwnCodeBlock is text between ``` fences. Text holds the code.
+	MrkdwnCodeBlock
+	// MrkdwnText is plain text with entities decoded.
+	MrkdwnText
+	// MrkdwnLineBreak is a newline inside a paragraph.
+	MrkdwnLineBreak
+	// MrkdwnBold is *bold* text.
+	MrkdwnBold
+	// MrkdwnItalic is _italic_ text.
+	MrkdwnItalic
+	// MrkdwnStrike is ~struck~ text.
+	MrkdwnStrike
+	// MrkdwnCode is `code`. Text holds the code.
+	MrkdwnCode
+	// MrkdwnLink is <url|label> or <url>. URL holds the URL and Text the label, if any.
+	MrkdwnLink
+	// MrkdwnUserMention is <@U123>. ID holds the user ID and Text the label, if any.
+	MrkdwnUserMention
+	// MrkdwnChannelMention is <#C123|name>. ID holds the channel ID and Text the name, if any.
+	MrkdwnChannelMention
+	// MrkdwnUserGroupMention is <!subteam^S123>. ID holds the user group ID and Text the handle, if any.
+	MrkdwnUserGroupMention
+	// MrkdwnBroadcast is <!here>, <!channel> or <!everyone>. ID holds the range, such as "here".
+	MrkdwnBroadcast
+	// MrkdwnDate is <!date^timestamp^format^link|fallback>. Timestamp, Format and URL
+	// hold the parts of the token and Text the fallback.
+	MrkdwnDate
+	// MrkdwnEmoji is an :emoji: shortcode. ID holds the name.
+	MrkdwnEmoji
+)
+
+// MrkdwnNode is a node of parsed Slack mrkdwn.
+type MrkdwnNode struct {
+	Kind      MrkdwnKind
+	Text      string
+	ID        string
+	URL       string
+	Timestamp int64
+	Format    string
+	Children  []*MrkdwnNode
+}
+
+// ParseMrkdwn parses text in Slack's mrkdwn dialect. Entities such as &gt; are decoded,
+// and markers that Slack does not read as formatting are kept as text.
+func ParseMrkdwn(text string) *MrkdwnNode {
+	doc := &MrkdwnNode{Kind: MrkdwnDocument}
+	rest := text
+	for {
+		start := strings.Index(rest, "```")
+		end := -1
+		if start >= 0 {
+			end = strings.Index(rest[start+3:], "```")
+		}
+		if end < 0 {
+			doc.Children = append(doc.Children, parseMrkdwnLines(rest)...)
+			return doc
+		}
+
+		code := rest[start+3 : start+3+end]
+		doc.Children = append(doc.Children, parseMrkdwnLines(strings.TrimSuffix(rest[:start], "\n"))...)
+		doc.Children = append(doc.Children, &MrkdwnNode{
+			Kind: MrkdwnCodeBlock,
+			Text: html.UnescapeString(strings.TrimSuffix(strings.TrimPrefix(code, "\n"), "\n")),
+		})
+		rest = strings.TrimPrefix(rest[start+3+end+3:], "\n")
+	}
+}
+
+// ConvertMrkdwnToMarkdown converts Slack mrkdwn text to CommonMark with GFM strikethrough.
+// Mentions and emoji are written as configured by the options, as in ConvertBlocksToMarkdown.
+func ConvertMrkdwnToMarkdown(mrkdwn string, opts ...MarkdownOption) string {
+	return ConvertMrkdwnNodeToMarkdown(ParseMrkdwn(mrkdwn), opts...)
+}
+
+// ConvertMrkdwnNodeToMarkdown converts a parsed mrkdwn node and its children to markdown.
+func ConvertMrkdwnNodeToMarkdown(n *MrkdwnNode, opts ...MarkdownOption) string {
+	w := newMarkdownWriter(opts)
+	switch n.Kind {
+	case MrkdwnDocument:
+		return w.mrkdwnBlocks(n.Children)
+	case MrkdwnParagraph, MrkdwnQuote, MrkdwnCodeBlock:
+		return w.mrkdwnBlock(n)
+	}
+	return w.mrkdwnInline([]*MrkdwnNode{n})
+}
+
+// parseMrkdwnLines parses lines without code blocks into paragraphs and quotes.
+func parseMrkdwnLines(text string) []*MrkdwnNode {
+	if text == "" {
+		return nil
+	}
+
+	var nodes []*MrkdwnNode
+	var paragraph, quote []string
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			nodes = append(nodes, &MrkdwnNode{Kind: MrkdwnParagraph, Children: parseMrkdwnInline(strings.Join(paragraph, "\n"))})
+			paragraph = nil
+		}
+	}
+	flushQuote := func() {
+		if len(quote) > 0 {
+			nodes = append(nodes, &MrkdwnNode{Kind: MrkdwnQuote, Children: parseMrkdwnLines(strings.Join(quote, "\n"))})
+			quote = nil
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if rest, ok := cutQuoteMarker(line, ">>>"); ok {
+			flushParagraph()
+			quote = append(quote, rest)
+			quote = append(quote, lines[i+1:]...)
+			break
+		}
+		if rest, ok := cutQuoteMarker(line, ">"); ok {
+			flushParagraph()
+			quote = append(quote, rest)
+			continue
+		}
+		flushQuote()
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flushParagraph()
+	flushQuote()
+
+	return nodes
+}
+
+// cutQuoteMarker removes a quote marker, raw or as entities, and the space after it from line.
+func cutQuoteMarker(line, marker string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, marker)
+	if !ok {
+		rest, ok = strings.CutPrefix(line, strings.ReplaceAll(marker, ">", "&gt;"))
+	}
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, " "), true
+}
+
+// emojiPattern matches an emoji shortcode at the start of a string.
+var emojiPattern = regexp.MustCompile(`^:[a-z0-9_+'-]+:`)
+
+// parseMrkdwnInline parses the inline formatting of a paragraph.
+func parseMrkdwnInline(text string) []*MrkdwnNode {
+	var nodes []*MrkdwnNode
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			nodes = append(nodes, &MrkdwnNode{Kind: MrkdwnText, Text: html.UnescapeString(plain.String())})
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		switch ch := text[i]; ch {
+		case '\n':
+			flush()
+			nodes = append(nodes, &MrkdwnNode{Kind: MrkdwnLineBreak})
+			i++
+			continue
+
+		case '`':
+			if end := strings.IndexByte(text[i+1:], '`'); end > 0 {
+				flush()
+				nodes = append(nodes, &MrkdwnNode{Kind: MrkdwnCode, Text: html.UnescapeString(text[i+1 : i+1+end])})
+				i += end + 2
+				continue
+			}
+
+		case '<':
+			if end := strings.IndexAny(text[i+1:], "<>\n"); end > 0 && text[i+1+end] == '>' {
+				flush()
+				nodes = append(nodes, parseMrkdwnAngle(text[i+1:i+1+end]))
+				i += end + 2
+				continue
+			}
+
+		case '*', '_', '~':
+			if end := mrkdwnEmphasisEnd(text, i); end > 0 {
+				flush()
+				kind := MrkdwnBold
+				if ch == '_' {
+					kind = MrkdwnItalic
+				} else if ch == '~' {
+					kind = MrkdwnStrike
+				}
+				nodes = append(nodes, &MrkdwnNode{Kind: kind, Children: parseMrkdwnInline(text[i+1 : end])})
+				i = end + 1
+				continue
+			}
+
+		case ':':
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			if match := emojiPattern.FindString(text[i:]); match != "" && !isWordRune(prev) {
+				flush()
+				nodes = append(nodes, &MrkdwnNode{Kind: MrkdwnEmoji, ID: match[1 : len(match)-1]})
+				i += len(match)
+				continue
+			}
+		}
+		plain.WriteByte(text[i])
+		i++
+	}
+	flush()
+
+	return nodes
+}
+
+// mrkdwnEmphasisEnd returns the index of the marker closing the emphasis opened at
+// text[start], or -1 if Slack does not read it as emphasis. Emphasis markers must be
+// outside words, the emphasized text must not start or end with a space, and
+// emphasis does not span lines.
+func mrkdwnEmphasisEnd(text string, start int) int {
+	ch := text[start]
+	prev, _ := utf8.DecodeLastRuneInString(text[:start])
+	next, _ := utf8.DecodeRuneInString(text[start+1:])
+	if start > 0 && isWordRune(prev) || start+1 >= len(text) || unicode.IsSpace(next) || next == rune(ch) {
+		return -1
+	}
+
+	for end := start + 2; end < len(text); end++ {
+		if text[end] == '\n' {
+			return -1
+		}
+		if text[end] != ch {
+			continue
+		}
+		before, _ := utf8.DecodeLastRuneInString(text[:end])
+		after, _ := utf8.DecodeRuneInString(text[end+1:])
+		if !unicode.IsSpace(before) && (end+1 == len(text) || !isWordRune(after)) {
+			return end
+		}
+	}
+	return -1
+}
+
+// parseMrkdwnAngle parses the content of an angle bracket token, such as a link,
+// a mention or a date.
+func parseMrkdwnAngle(content string) *MrkdwnNode {
+	target, label, _ := strings.Cut(content, "|")
+	label = html.UnescapeString(label)
+
+	switch {
+	case strings.HasPrefix(target, "@"):
+		return &MrkdwnNode{Kind: MrkdwnUserMention, ID: target[1:], Text: label}
+	case strings.HasPrefix(target, "#"):
+		return &MrkdwnNode{Kind: MrkdwnChannelMention, ID: target[1:], Text: label}
+	case strings.HasPrefix(target, "!subteam^"):
+		return &MrkdwnNode{Kind: MrkdwnUserGroupMention, ID: strings.TrimPrefix(target, "!subteam^"), Text: label}
+	case strings.HasPrefix(target, "!date^"):
+		parts := strings.SplitN(strings.TrimPrefix(target, "!date^"), "^", 3)
+		node := &MrkdwnNode{Kind: MrkdwnDate, Text: label}
+		node.Timestamp, _ = strconv.ParseInt(parts[0], 10, 64)
+		if len(parts) > 1 {
+			node.Format = parts[1]
+		}
+		if len(parts) > 2 {
+			node.URL = html.UnescapeString(parts[2])
+		}
+		return node
+	case strings.HasPrefix(target, "!"):
+		return &MrkdwnNode{Kind: MrkdwnBroadcast, ID: target[1:], Text: label}
+	}
+	return &MrkdwnNode{Kind: MrkdwnLink, URL: html.UnescapeString(target), Text: label}
+}
+
+func (w *markdownWriter) mrkdwnBlocks(nodes []*MrkdwnNode) string {
+	parts := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		parts = append(parts, w.mrkdwnBlock(n))
+	}
+	return joinNonEmpty(parts, "\n\n")
+}
+
+func (w *markdownWriter) mrkdwnBlock(n *MrkdwnNode) string {
+	switch n.Kind {
+	case MrkdwnParagraph:
+		return bulletLines(escapeLineStarts(w.mrkdwnInline(n.Children)))
+	case MrkdwnQuote:
+		return prefixLines(w.mrkdwnBlocks(n.Children), "> ")
+	case MrkdwnCodeBlock:
+		return codeFence(n.Text)
+	}
+	return ""
+}
+
+func (w *markdownWriter) mrkdwnInline(nodes []*MrkdwnNode) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case MrkdwnText:
+			sb.WriteString(escapeInlineMarkdown(n.Text))
+		case MrkdwnLineBreak:
+			sb.WriteString("\n")
+		case MrkdwnBold:
+			sb.WriteString("**" + w.mrkdwnInline(n.Children) + "**")
+		case MrkdwnItalic:
+			sb.WriteString("*" + w.mrkdwnInline(n.Children) + "*")
+		case MrkdwnStrike:
+			sb.WriteString("~" + w.mrkdwnInline(n.Children) + "~")
+		case MrkdwnCode:
+			sb.WriteString(codeSpan(n.Text))
+		case MrkdwnLink:
+			if n.Text == "" {
+				sb.WriteString("<" + n.URL + ">")
+			} else {
+				sb.WriteString("[" + escapeInlineMarkdown(n.Text) + "](" + linkDestination(n.URL) + ")")
+			}
+		case MrkdwnUserMention:
+			sb.WriteString(w.cfg.user(n.ID))
+		case MrkdwnChannelMention:
+			sb.WriteString(w.cfg.channel(n.ID))
+		case MrkdwnUserGroupMention:
+			sb.WriteString(w.cfg.userGroup(n.ID))
+		case MrkdwnBroadcast:
+			sb.WriteString(w.cfg.broadcast(n.ID))
+		case MrkdwnDate:
+			sb.WriteString(escapeInlineMarkdown(n.Text))
+		case MrkdwnEmoji:
+			sb.WriteString(w.cfg.emoji(n.ID, ""))
+		}
+	}
+	return sb.String()
+}
+
+// bulletLines turns lines starting with the bullets Slack users type into markdown list items.
+func bulletLines(md string) string {
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "• "); ok {
+			lines[i] = "- " + rest
+		}
+	}
+	return strings.Join(lines, "\n")
+}
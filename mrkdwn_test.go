@@ -0,0 +1,183 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseMrkdwn(t *testing.T) {
+	tests := []struct {
+		name   string
+		mrkdwn string
+		want   []*MrkdwnNode
+	}{
+		{
+			name:   "emphasis",
+			mrkdwn: "*bold _both_* ~gone~",
+			want: []*MrkdwnNode{{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+				{Kind: MrkdwnBold, Children: []*MrkdwnNode{
+					{Kind: MrkdwnText, Text: "bold "},
+					{Kind: MrkdwnItalic, Children: []*MrkdwnNode{{Kind: MrkdwnText, Text: "both"}}},
+				}},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnStrike, Children: []*MrkdwnNode{{Kind: MrkdwnText, Text: "gone"}}},
+			}}},
+		},
+		{
+			name:   "markers that are not emphasis",
+			mrkdwn: "2 * 3 * 4 and snake_case_name and * spaced *",
+			want: []*MrkdwnNode{{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+				{Kind: MrkdwnText, Text: "2 * 3 * 4 and snake_case_name and * spaced *"},
+			}}},
+		},
+		{
+			name:   "angle tokens",
+			mrkdwn: "<@U1> <#C1|general> <!subteam^S1|@team> <!here> <https://example.com|docs> <mailto:a@example.com>",
+			want: []*MrkdwnNode{{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+				{Kind: MrkdwnUserMention, ID: "U1"},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnChannelMention, ID: "C1", Text: "general"},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnUserGroupMention, ID: "S1", Text: "@team"},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnBroadcast, ID: "here"},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnLink, URL: "https://example.com", Text: "docs"},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnLink, URL: "mailto:a@example.com"},
+			}}},
+		},
+		{
+			name:   "date",
+			mrkdwn: "<!date^1700000000^{date_short} at {time}^https://example.com|Nov 14, 2023>",
+			want: []*MrkdwnNode{{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+				{Kind: MrkdwnDate, Timestamp: 1700000000, Format: "{date_short} at {time}", URL: "https://example.com", Text: "Nov 14, 2023"},
+			}}},
+		},
+		{
+			name:   "entities, code and emoji",
+			mrkdwn: "a &amp; b &lt;c&gt; `x &lt; y` :tada: 12:30:00",
+			want: []*MrkdwnNode{{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+				{Kind: MrkdwnText, Text: "a & b <c> "},
+				{Kind: MrkdwnCode, Text: "x < y"},
+				{Kind: MrkdwnText, Text: " "},
+				{Kind: MrkdwnEmoji, ID: "tada"},
+				{Kind: MrkdwnText, Text: " 12:30:00"},
+			}}},
+		},
+		{
+			name:   "quotes, line breaks and code blocks",
+			mrkdwn: "&gt; quoted\n&gt; *more*\nline one\nline two\n```\nfunc() {}\n```\n&gt;&gt;&gt; rest\n\nof it",
+			want: []*MrkdwnNode{
+				{Kind: MrkdwnQuote, Children: []*MrkdwnNode{{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+					{Kind: MrkdwnText, Text: "quoted"},
+					{Kind: MrkdwnLineBreak},
+					{Kind: MrkdwnBold, Children: []*MrkdwnNode{{Kind: MrkdwnText, Text: "more"}}},
+				}}}},
+				{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{
+					{Kind: MrkdwnText, Text: "line one"},
+					{Kind: MrkdwnLineBreak},
+					{Kind: MrkdwnText, Text: "line two"},
+				}},
+				{Kind: MrkdwnCodeBlock, Text: "func() {}"},
+				{Kind: MrkdwnQuote, Children: []*MrkdwnNode{
+					{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{{Kind: MrkdwnText, Text: "rest"}}},
+					{Kind: MrkdwnParagraph, Children: []*MrkdwnNode{{Kind: MrkdwnText, Text: "of it"}}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseMrkdwn(tt.mrkdwn)
+			if got.Kind != MrkdwnDocument {
+				t.Fatalf("root kind mismatch, got=%v", got.Kind)
+			}
+			if !reflect.DeepEqual(got.Children, tt.want) {
+				t.Errorf("nodes mismatch\ngot:  %s\nwant: %s", dumpMrkdwn(got.Children), dumpMrkdwn(tt.want))
+			}
+		})
+	}
+}
+
+func TestConvertMrkdwnToMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		mrkdwn string
+		opts   []MarkdownOption
+		want   string
+	}{
+		{
+			name:   "formatting",
+			mrkdwn: "*bold* _italic_ ~strike~ `code` <https://example.com|docs>",
+			want:   "**bold** *italic* ~strike~ `code` [docs](https://example.com)",
+		},
+		{
+			name:   "literal markers are escaped",
+			mrkdwn: "2 * 3 and [not a link] and # not heading",
+			want:   "2 \\* 3 and \\[not a link] and # not heading",
+		},
+		{
+			name:   "line start markers are escaped",
+			mrkdwn: "# not heading\n1. not a list",
+			want:   "\\# not heading\n1\\. not a list",
+		},
+		{
+			name:   "bullets",
+			mrkdwn: "• one\n• two",
+			want:   "- one\n- two",
+		},
+		{
+			name:   "quote and code block",
+			mrkdwn: "&gt; quoted\n```a &amp; b```",
+			want:   "> quoted\n\n```\na & b\n```",
+		},
+		{
+			name:   "mention formats",
+			mrkdwn: "<@U1> <#C1|general> :wave:",
+			opts: []MarkdownOption{
+				MarkdownUserFormat(func(id string) string { return "@" + id }),
+				MarkdownChannelFormat(func(id string) string { return "#" + id }),
+				MarkdownEmojiFormat(func(name, _ string) string { return "👋" }),
+			},
+			want: "@U1 #C1 👋",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConvertMrkdwnToMarkdown(tt.mrkdwn, tt.opts...); got != tt.want {
+				t.Errorf("markdown mismatch\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertMrkdwnToMarkdownRoundTrip(t *testing.T) {
+	markdowns := []string{
+		"Some **bold**, *italic* and `code` text.",
+		"See [the docs](https://example.com/a_b) or <https://example.com>.",
+		"Ping <@U123> about 2 * 3 = 6.",
+	}
+
+	for _, markdown := range markdowns {
+		mrkdwn := convertInlineMarkdownToMrkdwn(markdown)
+		back := ConvertMrkdwnToMarkdown(mrkdwn)
+		if got := convertInlineMarkdownToMrkdwn(back); got != mrkdwn {
+			t.Errorf("round trip mismatch, got=%q, want=%q, markdown=%q", got, mrkdwn, back)
+		}
+	}
+}
+
+func dumpMrkdwn(nodes []*MrkdwnNode) string {
+	s := "["
+	for i, n := range nodes {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("{%d %q %q %q %s}", n.Kind, n.Text, n.ID, n.URL, dumpMrkdwn(n.Children))
+	}
+	return s + "]"
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"context"
+	"errors"
+
+	"github.com/slack-go/slack"
+)
+
+// ErrEmptyMessage is returned when markdown renders no blocks to post.
+var ErrEmptyMessage = errors.New("markdown renders no blocks")
+
+// OverflowMode controls how the messages after the first are posted when markdown
+// does not fit in a single message.
+type OverflowMode int
+
+const (
+	// OverflowThread posts the overflow as replies in the thread of the first message.
+	OverflowThread OverflowMode = iota
+	// OverflowMessages posts the overflow as follow-up messages in the channel.
+	OverflowMessages
+)
+
+// PostOption configures PostMarkdown.
+type PostOption func(*postConfig)
+
+type postConfig struct {
+	overflow   OverflowMode
+	threadTS   string
+	msgOptions []slack.MsgOption
+}
+
+// PostOverflow sets how the overflow of long markdown is posted. The default is OverflowThread.
+func PostOverflow(mode OverflowMode) PostOption {
+	return func(c *postConfig) {
+		c.overflow = mode
+	}
+}
+
+// PostThreadTS posts all messages as replies in the thread of the message with timestamp ts.
+func PostThreadTS(ts string) PostOption {
+	return func(c *postConfig) {
+		c.threadTS = ts
+	}
+}
+
+// PostMessageOptions adds options, such as slack.MsgOptionUsername, to every posted message.
+func PostMessageOptions(opts ...slack.MsgOption) PostOption {
+	return func(c *postConfig) {
+		c.msgOptions = append(c.msgOptions, opts...)
+	}
+}
+
+// PostMarkdown converts markdown to blocks and posts them to a channel, split into
+// as many messages as the blocks need. The first message gets the fallback text of
+// the whole document, and the others a plain text rendering of their own blocks.
+// It returns the timestamps of the posted messages in order, which are the ones
+// posted before the failure if posting fails.
+func PostMarkdown(ctx context.Context, client *slack.Client, channelID, markdown string, opts ...PostOption) ([]string, error) {
+	return defaultConverter.PostMarkdown(ctx, client, channelID, markdown, opts...)
+}
+
+// PostMarkdown converts markdown with the converter's settings and posts it, split
+// into messages of at most the converter's blocks per message.
+func (c *Converter) PostMarkdown(ctx context.Context, client *slack.Client, channelID, markdown string, opts ...PostOption) ([]string, error) {
+	cfg := postConfig{overflow: OverflowThread}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	chunks, err := c.ConvertMarkdownTextToBlockChunks(markdown)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, ErrEmptyMessage
+	}
+
+	var timestamps []string
+	threadTS := cfg.threadTS
+	for i, chunk := range chunks {
+		msgOpts := append(c.chunkMessageOptions(markdown, i, chunk), cfg.msgOptions...)
+		if threadTS != "" {
+			msgOpts = append(msgOpts, slack.MsgOptionTS(threadTS))
+		}
+
+		_, ts, err := client.PostMessageContext(ctx, channelID, msgOpts...)
+		if err != nil {
+			return timestamps, err
+		}
+		timestamps = append(timestamps, ts)
+
+		if i == 0 && threadTS == "" && cfg.overflow == OverflowThread {
+			threadTS = ts
+		}
+	}
+	return timestamps, nil
+}
+
+// chunkMessageOptions returns the text and blocks of the i-th message of markdown.
+func (c *Converter) chunkMessageOptions(markdown string, i int, chunk []slack.Block) []slack.MsgOption {
+	return []slack.MsgOption{
+		slack.MsgOptionText(c.chunkFallbackText(markdown, i, chunk), false),
+		slack.MsgOptionBlocks(chunk...),
+	}
+}
+
+// chunkFallbackText returns the fallback text of the i-th message of markdown: the
+// fallback text of the whole document for the first, and the plain text of its
+// blocks for the others.
+func (c *Converter) chunkFallbackText(markdown string, i int, chunk []slack.Block) string {
+	if i == 0 {
+		return c.ConvertMarkdownTextToFallbackText(markdown)
+	}
+	return c.blocksFallbackText(chunk)
+}
+
+// blocksFallbackText returns the plain text of blocks capped at the converter's
+// maximum fallback text length.
+func (c *Converter) blocksFallbackText(blocks []slack.Block) string {
+	text := RenderBlocksToTerminal(blocks, TerminalWidth(0), TerminalColor(false))
+	return truncateText(collapseSpaces(text), c.maxFallbackTextLength)
+}
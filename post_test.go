@@ -0,0 +1,195 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/slack-go/slack/slacktest"
+)
+
+// slackCall is a Web API call received by a test server.
+type slackCall struct {
+	method string
+	form   url.Values
+}
+
+// fakeResponse is how a test server answers a call instead of succeeding: with
+// a status code and Retry-After header, or with a Web API error.
+type fakeResponse struct {
+	status     int
+	retryAfter string
+	err        string
+}
+
+// failAt answers the call numbered n with a rate_limited error.
+func failAt(n int) func(int) *fakeResponse {
+	return func(call int) *fakeResponse {
+		if call == n {
+			return &fakeResponse{err: "rate_limited"}
+		}
+		return nil
+	}
+}
+
+// newPostServer starts a slacktest server for chat.postMessage, chat.update and
+// chat.delete. It answers the n-th call, counted from 1, with respond(n) when
+// respond is non-nil and returns a response, and otherwise records the call and
+// succeeds. Posted messages get increasing timestamps.
+func newPostServer(t *testing.T, respond func(n int) *fakeResponse) (*slack.Client, func() []slackCall) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []slackCall
+	n, posted := 0, 0
+	s := slacktest.NewTestServer(func(c slacktest.Customize) {
+		for _, method := range []string{"chat.postMessage", "chat.update", "chat.delete"} {
+			c.Handle("/"+method, func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Error(err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				n++
+				if respond != nil {
+					if resp := respond(n); resp != nil {
+						if resp.err != "" {
+							_, _ = fmt.Fprintf(w, `{"ok": false, "error": %q}`, resp.err)
+							return
+						}
+						w.Header().Set("Retry-After", resp.retryAfter)
+						w.WriteHeader(resp.status)
+						return
+					}
+				}
+				calls = append(calls, slackCall{method: method, form: r.PostForm})
+				ts := r.PostForm.Get("ts")
+				if method == "chat.postMessage" {
+					posted++
+					ts = fmt.Sprintf("1700000000.%06d", posted)
+				}
+				_, _ = fmt.Fprintf(w, `{"ok": true, "channel": %q, "ts": %q}`, r.PostForm.Get("channel"), ts)
+			})
+		}
+	})
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	client := slack.New("xoxb-test", slack.OptionAPIURL(s.GetAPIURL()))
+	return client, func() []slackCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(calls)
+	}
+}
+
+// postedForms returns the forms of the chat.postMessage calls.
+func postedForms(calls []slackCall) []url.Values {
+	var forms []url.Values
+	for _, call := range calls {
+		if call.method == "chat.postMessage" {
+			forms = append(forms, call.form)
+		}
+	}
+	return forms
+}
+
+func TestPostMarkdown(t *testing.T) {
+	markdown := "# One\n\nfirst\n\n# Two\n\nsecond\n\n# Three\n\nthird"
+	c := NewConverter(WithBlocksPerMessage(2))
+
+	tests := []struct {
+		name       string
+		opts       []PostOption
+		wantThread []string
+	}{
+		{
+			name:       "thread",
+			wantThread: []string{"", "1700000000.000001", "1700000000.000001"},
+		},
+		{
+			name:       "follow-up messages",
+			opts:       []PostOption{PostOverflow(OverflowMessages)},
+			wantThread: []string{"", "", ""},
+		},
+		{
+			name:       "existing thread",
+			opts:       []PostOption{PostThreadTS("1600000000.000000")},
+			wantThread: []string{"1600000000.000000", "1600000000.000000", "1600000000.000000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := newPostServer(t, nil)
+			timestamps, err := c.PostMarkdown(context.Background(), client, "C1", markdown, tt.opts...)
+			if err != nil {
+				t.Fatalf("PostMarkdown() error = %v", err)
+			}
+			if want := []string{"1700000000.000001", "1700000000.000002", "1700000000.000003"}; strings.Join(timestamps, " ") != strings.Join(want, " ") {
+				t.Errorf("timestamps = %q, want %q", timestamps, want)
+			}
+
+			posted := postedForms(calls())
+			if len(posted) != 3 {
+				t.Fatalf("posted %d messages, want 3", len(posted))
+			}
+			for i, form := range posted {
+				if got := form.Get("thread_ts"); got != tt.wantThread[i] {
+					t.Errorf("message %d thread_ts = %q, want %q", i, got, tt.wantThread[i])
+				}
+				if got := form.Get("channel"); got != "C1" {
+					t.Errorf("message %d channel = %q", i, got)
+				}
+			}
+			if got, want := posted[0].Get("text"), "One\nfirst Two second Three third"; got != want {
+				t.Errorf("first text = %q, want %q", got, want)
+			}
+			if got, want := posted[1].Get("text"), "Two second"; got != want {
+				t.Errorf("second text = %q, want %q", got, want)
+			}
+			if got := posted[2].Get("blocks"); !strings.Contains(got, `"text":"Three"`) || !strings.Contains(got, "third") {
+				t.Errorf("third blocks = %s", got)
+			}
+		})
+	}
+}
+
+func TestPostMarkdownMessageOptions(t *testing.T) {
+	client, calls := newPostServer(t, nil)
+	_, err := PostMarkdown(context.Background(), client, "C1", "*hi*", PostMessageOptions(slack.MsgOptionUsername("release-bot")))
+	if err != nil {
+		t.Fatalf("PostMarkdown() error = %v", err)
+	}
+	if got := postedForms(calls())[0].Get("username"); got != "release-bot" {
+		t.Errorf("username = %q", got)
+	}
+}
+
+func TestPostMarkdownErrors(t *testing.T) {
+	client, _ := newPostServer(t, failAt(2))
+	c := NewConverter(WithBlocksPerMessage(1))
+	timestamps, err := c.PostMarkdown(context.Background(), client, "C1", "one\n\ntwo\n\nthree")
+	if err == nil || err.Error() != "rate_limited" {
+		t.Errorf("PostMarkdown() error = %v, want rate_limited", err)
+	}
+	if len(timestamps) != 1 || timestamps[0] != "1700000000.000001" {
+		t.Errorf("timestamps = %q, want the first message", timestamps)
+	}
+
+	if _, err := PostMarkdown(context.Background(), client, "C1", "  \n"); !errors.Is(err, ErrEmptyMessage) {
+		t.Errorf("PostMarkdown() error = %v, want ErrEmptyMessage", err)
+	}
+
+	var limitErr *LimitError
+	_, err = NewConverter(WithMaxInputSize(1)).PostMarkdown(context.Background(), client, "C1", "too long")
+	if !errors.As(err, &limitErr) {
+		t.Errorf("PostMarkdown() error = %v, want a LimitError", err)
+	}
+}
@@ -0,0 +1,378 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// ErrPosterClosed is the error of messages queued after a Poster was shut down.
+var ErrPosterClosed = errors.New("poster is shut down")
+
+// defaultPosterRates are the calls per minute allowed by the rate limit tiers of
+// common methods. chat.postMessage allows about one message per second per channel.
+var defaultPosterRates = map[string]int{
+	"chat.postMessage":   60,
+	"chat.update":        50,
+	"chat.delete":        50,
+	"chat.postEphemeral": 100,
+	"reactions.add":      50,
+	"files.uploadV2":     20,
+}
+
+// transientSlackErrors are the Web API errors worth retrying.
+var transientSlackErrors = []string{"internal_error", "fatal_error", "service_unavailable", "request_timeout"}
+
+// PostResult is the outcome of messages posted by a Poster.
+type PostResult struct {
+	// ChannelID is the channel the messages were posted to.
+	ChannelID string
+	// Timestamps are the timestamps of the posted messages, in order.
+	Timestamps []string
+	// Err is the error that stopped posting, or nil if all messages were posted.
+	Err error
+}
+
+// PosterOption configures a Poster.
+type PosterOption func(*posterConfig)
+
+type posterConfig struct {
+	rates      map[string]int
+	maxRetries int
+	backoff    time.Duration
+	msgOptions []slack.MsgOption
+}
+
+// PosterRateLimit sets how many calls of a Web API method the Poster makes per
+// minute, per channel for chat.postMessage and per workspace for other methods.
+// Methods without a rate are not limited until Slack responds with 429.
+func PosterRateLimit(method string, perMinute int) PosterOption {
+	return func(c *posterConfig) {
+		if perMinute > 0 {
+			c.rates[method] = perMinute
+		}
+	}
+}
+
+// PosterRetry sets how many times a call is retried after a transient error or a
+// rate limit, and the wait before the first retry after a transient error, which
+// doubles with every retry. The default is 5 retries starting at one second.
+func PosterRetry(maxRetries int, backoff time.Duration) PosterOption {
+	return func(c *posterConfig) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+		if backoff > 0 {
+			c.backoff = backoff
+		}
+	}
+}
+
+// PosterMessageOptions adds options, such as slack.MsgOptionUsername, to every posted message.
+func PosterMessageOptions(opts ...slack.MsgOption) PosterOption {
+	return func(c *posterConfig) {
+		c.msgOptions = append(c.msgOptions, opts...)
+	}
+}
+
+// Poster posts messages to many channels from a queue without exceeding Slack's
+// rate limits. Messages queued for a channel are posted in order, one batch after
+// another, while channels are posted to concurrently. Calls wait for the budget of
+// their method, wait for the Retry-After of rate limited responses, and are retried
+// with exponential backoff after transient errors such as 5xx responses.
+//
+// A Poster is safe for concurrent use.
+type Poster struct {
+	client *slack.Client
+	cfg    posterConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	queues   map[string][]*posterJob
+	limiters map[string]*rateLimiter
+}
+
+type posterJob struct {
+	ctx       context.Context
+	channelID string
+	messages  []Message
+	result    chan PostResult
+	// stop stops dropping the job from its queue when ctx is done.
+	stop func() bool
+}
+
+// NewPoster creates a Poster that posts with client.
+func NewPoster(client *slack.Client, opts ...PosterOption) *Poster {
+	cfg := posterConfig{rates: map[string]int{}, maxRetries: 5, backoff: time.Second}
+	for method, rate := range defaultPosterRates {
+		cfg.rates[method] = rate
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Poster{
+		client:   client,
+		cfg:      cfg,
+		ctx:      ctx,
+		cancel:   cancel,
+		queues:   map[string][]*posterJob{},
+		limiters: map[string]*rateLimiter{},
+	}
+}
+
+// Post queues messages, such as the ones of ConvertMarkdownTextToThread, to be posted
+// to a channel after the messages queued for it before. Replies are posted in the
+// thread of the last message before them that is not a reply. The returned channel
+// receives the result once the messages are posted, posting fails or ctx is done.
+func (p *Poster) Post(ctx context.Context, channelID string, messages []Message) <-chan PostResult {
+	job := &posterJob{ctx: ctx, channelID: channelID, messages: messages, result: make(chan PostResult, 1)}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		job.result <- PostResult{ChannelID: channelID, Err: ErrPosterClosed}
+		return job.result
+	}
+
+	queue, running := p.queues[channelID]
+	p.queues[channelID] = append(queue, job)
+	job.stop = context.AfterFunc(ctx, func() { p.drop(job) })
+	if !running {
+		p.wg.Add(1)
+		go p.work(channelID)
+	}
+	return job.result
+}
+
+// drop removes a job whose context is done from its queue and fails it. Jobs already
+// taken from the queue fail when their calls see the context.
+func (p *Poster) drop(job *posterJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	queue := p.queues[job.channelID]
+	if i := slices.Index(queue, job); i >= 0 {
+		p.queues[job.channelID] = slices.Delete(queue, i, i+1)
+		job.result <- PostResult{ChannelID: job.channelID, Err: job.ctx.Err()}
+	}
+}
+
+// Shutdown stops accepting messages and waits until the queued messages are posted.
+// If ctx is done first, the calls in progress are canceled, the messages still
+// queued fail with context.Canceled, and Shutdown returns the context's error.
+func (p *Poster) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// work posts the jobs queued for a channel until the queue is empty.
+func (p *Poster) work(channelID string) {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		queue := p.queues[channelID]
+		if len(queue) == 0 {
+			delete(p.queues, channelID)
+			p.evictIdleLimiters()
+			p.mu.Unlock()
+			return
+		}
+		job := queue[0]
+		p.queues[channelID] = queue[1:]
+		p.mu.Unlock()
+		job.stop()
+
+		job.result <- p.postJob(job)
+	}
+}
+
+func (p *Poster) postJob(job *posterJob) PostResult {
+	ctx, cancel := context.WithCancel(job.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.ctx, cancel)
+	defer stop()
+
+	result := PostResult{ChannelID: job.channelID}
+	var threadTS string
+	for _, msg := range job.messages {
+		if p.ctx.Err() != nil {
+			result.Err = context.Canceled
+			return result
+		}
+
+		msgOpts := append([]slack.MsgOption{
+			slack.MsgOptionText(msg.Text, false),
+			slack.MsgOptionBlocks(msg.Blocks...),
+		}, p.cfg.msgOptions...)
+		if msg.Reply && threadTS != "" {
+			msgOpts = append(msgOpts, slack.MsgOptionTS(threadTS))
+		}
+
+		var ts string
+		err := p.Do(ctx, "chat.postMessage", job.channelID, func(ctx context.Context) error {
+			var err error
+			_, ts, err = p.client.PostMessageContext(ctx, job.channelID, msgOpts...)
+			return err
+		})
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Timestamps = append(result.Timestamps, ts)
+		if !msg.Reply {
+			threadTS = ts
+		}
+	}
+	return result
+}
+
+// Do calls fn, a call of a Web API method in a channel, within the Poster's budget
+// for the method, and retries it when it fails with a rate limit or a transient error.
+// Use it to make other calls, such as chat.update, share the Poster's rate limits.
+func (p *Poster) Do(ctx context.Context, method, channelID string, fn func(ctx context.Context) error) error {
+	limiter := p.limiter(method, channelID)
+	defer p.release(limiter)
+	for retry := 0; ; retry++ {
+		if err := limiter.wait(ctx); err != nil {
+			return err
+		}
+		err := fn(ctx)
+		if err == nil || retry >= p.cfg.maxRetries || ctx.Err() != nil {
+			return err
+		}
+
+		var rateLimited *slack.RateLimitedError
+		switch {
+		case errors.As(err, &rateLimited):
+			limiter.pause(rateLimited.RetryAfter)
+		case isTransientSlackError(err):
+			if err := sleepContext(ctx, p.cfg.backoff<<retry); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// limiter returns the rate limiter of a method, which is per channel for chat.postMessage,
+// and holds it until it is released.
+func (p *Poster) limiter(method, channelID string) *rateLimiter {
+	key := method
+	if method == "chat.postMessage" {
+		key += " " + channelID
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l, ok := p.limiters[key]
+	if !ok {
+		l = &rateLimiter{}
+		if rate := p.cfg.rates[method]; rate > 0 {
+			l.interval = time.Minute / time.Duration(rate)
+		}
+		p.limiters[key] = l
+	}
+	l.users++
+	return l
+}
+
+// release lets go of a limiter returned by limiter.
+func (p *Poster) release(l *rateLimiter) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	l.users--
+}
+
+// evictIdleLimiters removes the limiters nobody holds whose next call may be made
+// now, which a new limiter replaces without changing the rate. It keeps the limiters
+// of channels posted to once from piling up. p.mu must be held.
+func (p *Poster) evictIdleLimiters() {
+	now := time.Now()
+	for key, l := range p.limiters {
+		if l.users == 0 && !l.reserved(now) {
+			delete(p.limiters, key)
+		}
+	}
+}
+
+// isTransientSlackError reports whether err is likely to go away on a retry.
+func isTransientSlackError(err error) bool {
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	var slackErr slack.SlackErrorResponse
+	if errors.As(err, &slackErr) {
+		return slices.Contains(transientSlackErrors, slackErr.Err)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// rateLimiter spaces calls at least interval apart, and holds them back while paused.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+
+	// users is the number of calls holding the limiter, guarded by the Poster's mu.
+	users int
+}
+
+// reserved reports whether calls are held back at now.
+func (l *rateLimiter) reserved(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.next.After(now)
+}
+
+// wait reserves the next call and waits until it may be made.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	return sleepContext(ctx, at.Sub(now))
+}
+
+// pause holds back calls for d from now.
+func (l *rateLimiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.next) {
+		l.next = until
+	}
+}
@@ -0,0 +1,267 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestPoster(t *testing.T) {
+	client, calls := newPostServer(t, nil)
+	p := NewPoster(client, PosterRateLimit("chat.postMessage", 60000))
+
+	ctx := context.Background()
+	thread := []Message{
+		{Text: "parent"},
+		{Text: "reply 1", Reply: true},
+		{Text: "next parent"},
+		{Text: "reply 2", Reply: true},
+	}
+	first := p.Post(ctx, "C1", thread)
+	second := p.Post(ctx, "C1", []Message{{Text: "after"}})
+	other := p.Post(ctx, "C2", []Message{{Text: "elsewhere"}, {Text: "reply 3", Reply: true}})
+
+	results := []PostResult{<-first, <-second, <-other}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("%s: %v", result.ChannelID, result.Err)
+		}
+	}
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	tsOf := map[string]string{}
+	var order []string
+	for _, form := range postedForms(calls()) {
+		ts := fmt.Sprintf("1700000000.%06d", len(tsOf)+1)
+		tsOf[form.Get("text")] = ts
+		if form.Get("channel") == "C1" {
+			order = append(order, form.Get("text"))
+		}
+		if want := map[string]string{
+			"reply 1": "parent",
+			"reply 2": "next parent",
+			"reply 3": "elsewhere",
+		}[form.Get("text")]; form.Get("thread_ts") != tsOf[want] {
+			t.Errorf("%q thread_ts = %q, want the ts of %q", form.Get("text"), form.Get("thread_ts"), want)
+		}
+	}
+	if got := strings.Join(order, "|"); got != "parent|reply 1|next parent|reply 2|after" {
+		t.Errorf("C1 order = %q", got)
+	}
+	if got := results[0].Timestamps; len(got) != 4 || got[0] != tsOf["parent"] || got[3] != tsOf["reply 2"] {
+		t.Errorf("timestamps = %q", got)
+	}
+	if got := results[2].Timestamps; len(got) != 2 || got[1] != tsOf["reply 3"] {
+		t.Errorf("C2 timestamps = %q", got)
+	}
+
+	if result := <-p.Post(ctx, "C1", thread); !errors.Is(result.Err, ErrPosterClosed) {
+		t.Errorf("Post() after Shutdown() error = %v, want ErrPosterClosed", result.Err)
+	}
+}
+
+func TestPosterRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(n int) *fakeResponse
+		wantErr string
+		want    int
+		minTime time.Duration
+	}{
+		{
+			name: "honours Retry-After",
+			respond: func(n int) *fakeResponse {
+				if n == 1 {
+					return &fakeResponse{status: http.StatusTooManyRequests, retryAfter: "1"}
+				}
+				return nil
+			},
+			want:    1,
+			minTime: time.Second,
+		},
+		{
+			name: "retries server errors",
+			respond: func(n int) *fakeResponse {
+				if n <= 2 {
+					return &fakeResponse{status: http.StatusServiceUnavailable}
+				}
+				return nil
+			},
+			want: 1,
+		},
+		{
+			name: "retries transient API errors",
+			respond: func(n int) *fakeResponse {
+				if n == 1 {
+					return &fakeResponse{err: "internal_error"}
+				}
+				return nil
+			},
+			want: 1,
+		},
+		{
+			name:    "gives up after the maximum retries",
+			respond: func(int) *fakeResponse { return &fakeResponse{status: http.StatusInternalServerError} },
+			wantErr: "slack server error: 500 Internal Server Error",
+		},
+		{
+			name:    "does not retry other errors",
+			respond: func(int) *fakeResponse { return &fakeResponse{err: "channel_not_found"} },
+			wantErr: "channel_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := newPostServer(t, tt.respond)
+			p := NewPoster(client, PosterRateLimit("chat.postMessage", 60000), PosterRetry(3, time.Millisecond))
+
+			start := time.Now()
+			result := <-p.Post(context.Background(), "C1", []Message{{Text: "hello"}})
+			if tt.wantErr != "" {
+				if result.Err == nil || result.Err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %s", result.Err, tt.wantErr)
+				}
+			} else if result.Err != nil {
+				t.Errorf("error = %v", result.Err)
+			}
+			if got := len(postedForms(calls())); got != tt.want {
+				t.Errorf("posted %d messages, want %d", got, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minTime {
+				t.Errorf("posted after %v, want at least %v", elapsed, tt.minTime)
+			}
+		})
+	}
+}
+
+func TestPosterRateLimit(t *testing.T) {
+	client, _ := newPostServer(t, nil)
+	p := NewPoster(client, PosterRateLimit("chat.postMessage", 600))
+
+	start := time.Now()
+	same := p.Post(context.Background(), "C1", []Message{{Text: "1"}, {Text: "2"}, {Text: "3"}})
+	other := p.Post(context.Background(), "C2", []Message{{Text: "1"}})
+	if result := <-other; result.Err != nil || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("other channel waited %v, error = %v", time.Since(start), result.Err)
+	}
+	if result := <-same; result.Err != nil || time.Since(start) < 200*time.Millisecond {
+		t.Errorf("same channel took %v, error = %v", time.Since(start), result.Err)
+	}
+
+	var calls int
+	err := p.Do(context.Background(), "chat.update", "C1", func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return &slack.RateLimitedError{}
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Do() = %v after %d calls", err, calls)
+	}
+}
+
+func TestPosterCancelQueued(t *testing.T) {
+	client, calls := newPostServer(t, func(n int) *fakeResponse {
+		if n == 1 {
+			return &fakeResponse{status: http.StatusTooManyRequests, retryAfter: "1"}
+		}
+		return nil
+	})
+	p := NewPoster(client)
+
+	blocked := p.Post(context.Background(), "C1", []Message{{Text: "waits for Retry-After"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := p.Post(ctx, "C1", []Message{{Text: "queued"}})
+	last := p.Post(context.Background(), "C1", []Message{{Text: "last"}})
+	cancel()
+
+	select {
+	case result := <-queued:
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("queued error = %v, want context.Canceled", result.Err)
+		}
+	case <-blocked:
+		t.Fatal("queued result arrived after the job before it")
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("queued result did not arrive when its context was canceled")
+	}
+
+	for _, result := range []PostResult{<-blocked, <-last} {
+		if result.Err != nil {
+			t.Errorf("Post() error = %v", result.Err)
+		}
+	}
+	if got := postedForms(calls()); len(got) != 2 || got[1].Get("text") != "last" {
+		t.Errorf("posted %v", got)
+	}
+}
+
+func TestPosterEvictsIdleLimiters(t *testing.T) {
+	client, _ := newPostServer(t, nil)
+	p := NewPoster(client, PosterRateLimit("chat.postMessage", 60000))
+
+	for i := range 20 {
+		if result := <-p.Post(context.Background(), fmt.Sprintf("C%d", i), []Message{{Text: "x"}}); result.Err != nil {
+			t.Fatalf("Post() error = %v", result.Err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if result := <-p.Post(context.Background(), "last", []Message{{Text: "x"}}); result.Err != nil {
+		t.Fatalf("Post() error = %v", result.Err)
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key := range p.limiters {
+		if key != "chat.postMessage last" {
+			t.Errorf("limiter %q was not evicted", key)
+		}
+	}
+}
+
+func TestPosterShutdown(t *testing.T) {
+	client, calls := newPostServer(t, func(n int) *fakeResponse {
+		if n == 1 {
+			return &fakeResponse{status: http.StatusTooManyRequests, retryAfter: "60"}
+		}
+		return nil
+	})
+	p := NewPoster(client)
+
+	blocked := p.Post(context.Background(), "C1", []Message{{Text: "waits for Retry-After"}})
+	queued := p.Post(context.Background(), "C1", []Message{{Text: "queued"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+	if result := <-blocked; !errors.Is(result.Err, context.Canceled) {
+		t.Errorf("blocked error = %v, want context.Canceled", result.Err)
+	}
+	if result := <-queued; !errors.Is(result.Err, context.Canceled) {
+		t.Errorf("queued error = %v, want context.Canceled", result.Err)
+	}
+	if n := len(postedForms(calls())); n != 0 {
+		t.Errorf("posted %d messages", n)
+	}
+
+	canceled, cancelPost := context.WithCancel(context.Background())
+	cancelPost()
+	if result := <-NewPoster(client).Post(canceled, "C1", []Message{{Text: "x"}}); !errors.Is(result.Err, context.Canceled) {
+		t.Errorf("Post() with a canceled context error = %v, want context.Canceled", result.Err)
+	}
+}
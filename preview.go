@@ -0,0 +1,537 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+// previewStyle approximates the look of Slack messages.
+const previewStyle = `body {
+	margin: 0;
+	padding: 20px;
+	background: #fff;
+	color: #1d1c1d;
+	font: 15px/1.46668 Lato, -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif;
+}
+.message { max-width: 720px; }
+.block { margin: 4px 0; }
+a { color: #1264a3; text-decoration: none; }
+p { margin: 0; }
+h1.header { margin: 8px 0 4px; font-size: 18px; font-weight: 900; line-height: 1.33; }
+hr.divider { margin: 12px 0; border: 0; border-top: 1px solid #ddd; }
+code {
+	padding: 2px 3px 1px;
+	border: 1px solid rgba(29, 28, 29, .13);
+	border-radius: 3px;
+	background: rgba(29, 28, 29, .04);
+	color: #e01e5a;
+	font: 12px Monaco, Menlo, Consolas, "Courier New", monospace;
+}
+pre {
+	margin: 4px 0;
+	padding: 8px;
+	border: 1px solid rgba(29, 28, 29, .13);
+	border-radius: 4px;
+	background: rgba(29, 28, 29, .04);
+	font: 12px/1.5 Monaco, Menlo, Consolas, "Courier New", monospace;
+	white-space: pre-wrap;
+	word-break: break-word;
+}
+blockquote { margin: 4px 0; padding-left: 16px; border-left: 4px solid #ddd; }
+ul, ol { margin: 0; padding-left: 22px; }
+ul.border, ol.border { border-left: 4px solid #ddd; }
+.mention { padding: 0 2px; border-radius: 3px; background: rgba(29, 155, 209, .1); color: #1264a3; }
+.broadcast { padding: 0 2px; border-radius: 3px; background: rgba(242, 199, 68, .4); }
+.emoji { color: #616061; }
+.color { display: inline-block; width: 10px; height: 10px; margin-right: 3px; border-radius: 2px; }
+.section { display: flex; gap: 12px; }
+.section-body { flex: 1; }
+.fields { display: grid; grid-template-columns: 1fr 1fr; gap: 4px 16px; margin-top: 4px; }
+.accessory img { width: 88px; height: 88px; border-radius: 4px; object-fit: cover; }
+.image img { max-width: 360px; max-height: 360px; border-radius: 4px; }
+.image .title { color: #616061; font-size: 13px; }
+.context { display: flex; flex-wrap: wrap; align-items: center; gap: 4px 8px; color: #616061; font-size: 12px; }
+.context img { width: 20px; height: 20px; border-radius: 3px; }
+.actions { display: flex; flex-wrap: wrap; gap: 8px; margin: 8px 0; }
+.button {
+	display: inline-block;
+	padding: 0 12px;
+	border: 1px solid rgba(29, 28, 29, .3);
+	border-radius: 4px;
+	color: #1d1c1d;
+	font-size: 13px;
+	font-weight: 700;
+	line-height: 26px;
+}
+.button.primary { border-color: #007a5a; background: #007a5a; color: #fff; }
+.button.danger { border-color: #e01e5a; background: #e01e5a; color: #fff; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 8px; border: 1px solid #ddd; vertical-align: top; }
+.unsupported { padding: 4px 8px; border: 1px dashed #ddd; color: #616061; font-size: 13px; }
+`
+
+// previewBulletStyles and previewNumberStyles are the list markers of each
+// indent level, which Slack cycles through.
+var (
+	previewBulletStyles = []string{"disc", "circle", "square"}
+	previewNumberStyles = []string{"decimal", "lower-alpha", "lower-roman"}
+)
+
+// previewColorPattern matches the hex colors that are shown with a swatch.
+var previewColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// RenderBlocksToHTML renders blocks as a self-contained HTML page that approximates
+// how Slack displays them, for previews, screenshots and golden files. Mentions are
+// shown by their IDs and emoji without a unicode code point by their shortcode.
+// Blocks without a preview, such as inputs, are shown as placeholders.
+func RenderBlocksToHTML(blocks []slack.Block) string {
+	var w previewWriter
+	w.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	w.WriteString("<title>Block Kit preview</title>\n<style>\n" + previewStyle + "</style>\n</head>\n<body>\n")
+	w.WriteString("<div class=\"message\">\n")
+	for _, block := range blocks {
+		if block != nil {
+			w.block(block)
+			w.WriteString("\n")
+		}
+	}
+	w.WriteString("</div>\n</body>\n</html>\n")
+	return w.String()
+}
+
+// BlockKitBuilderURL returns a link that opens blocks in Slack's Block Kit Builder.
+func BlockKitBuilderURL(blocks []slack.Block) (string, error) {
+	if blocks == nil {
+		blocks = []slack.Block{}
+	}
+	data, err := json.Marshal(struct {
+		Blocks []slack.Block `json:"blocks"`
+	}{blocks})
+	if err != nil {
+		return "", err
+	}
+	return "https://app.slack.com/block-kit-builder/#" + url.PathEscape(string(data)), nil
+}
+
+type previewWriter struct {
+	strings.Builder
+}
+
+func (w *previewWriter) block(block slack.Block) {
+	switch b := block.(type) {
+	case *slack.HeaderBlock:
+		w.header(b)
+	case slack.HeaderBlock:
+		w.header(&b)
+	case *slack.SectionBlock:
+		w.section(b)
+	case slack.SectionBlock:
+		w.section(&b)
+	case *slack.RichTextBlock:
+		w.richText(b)
+	case slack.RichTextBlock:
+		w.richText(&b)
+	case *slack.DividerBlock, slack.DividerBlock:
+		w.WriteString(`<hr class="block divider">`)
+	case *slack.ImageBlock:
+		w.image(b)
+	case slack.ImageBlock:
+		w.image(&b)
+	case *slack.ContextBlock:
+		w.context(b)
+	case slack.ContextBlock:
+		w.context(&b)
+	case *slack.ActionBlock:
+		w.actions(b)
+	case slack.ActionBlock:
+		w.actions(&b)
+	case *slack.TableBlock:
+		w.table(b)
+	case slack.TableBlock:
+		w.table(&b)
+	default:
+		fmt.Fprintf(w, `<div class="block unsupported">%s block</div>`, html.EscapeString(string(block.BlockType())))
+	}
+}
+
+func (w *previewWriter) header(b *slack.HeaderBlock) {
+	w.WriteString(`<h1 class="block header">`)
+	if b.Text != nil {
+		w.WriteString(html.EscapeString(b.Text.Text))
+	}
+	w.WriteString("</h1>")
+}
+
+func (w *previewWriter) section(b *slack.SectionBlock) {
+	w.WriteString(`<div class="block section"><div class="section-body">`)
+	if b.Text != nil {
+		w.text(b.Text)
+	}
+	if len(b.Fields) > 0 {
+		w.WriteString(`<div class="fields">`)
+		for _, field := range b.Fields {
+			if field != nil {
+				w.WriteString("<div>")
+				w.text(field)
+				w.WriteString("</div>")
+			}
+		}
+		w.WriteString("</div>")
+	}
+	w.WriteString("</div>")
+	if b.Accessory != nil {
+		if e := b.Accessory.ImageElement; e != nil && e.ImageURL != nil {
+			w.WriteString(`<div class="accessory">`)
+			w.img(*e.ImageURL, e.AltText)
+			w.WriteString("</div>")
+		}
+		if e := b.Accessory.ButtonElement; e != nil {
+			w.WriteString(`<div class="accessory">`)
+			w.button(e)
+			w.WriteString("</div>")
+		}
+	}
+	w.WriteString("</div>")
+}
+
+// text writes a text object, whose text is mrkdwn or plain text.
+func (w *previewWriter) text(t *slack.TextBlockObject) {
+	if t.Type != slack.MarkdownType {
+		w.WriteString("<p>" + previewText(t.Text) + "</p>")
+		return
+	}
+	for _, n := range ParseMrkdwn(t.Text).Children {
+		w.mrkdwnBlock(n)
+	}
+}
+
+func (w *previewWriter) mrkdwnBlock(n *MrkdwnNode) {
+	switch n.Kind {
+	case MrkdwnParagraph:
+		w.WriteString("<p>")
+		w.mrkdwnInline(n.Children)
+		w.WriteString("</p>")
+	case MrkdwnQuote:
+		w.WriteString("<blockquote>")
+		for _, child := range n.Children {
+			w.mrkdwnBlock(child)
+		}
+		w.WriteString("</blockquote>")
+	case MrkdwnCodeBlock:
+		w.WriteString("<pre>" + html.EscapeString(n.Text) + "</pre>")
+	}
+}
+
+func (w *previewWriter) mrkdwnInline(nodes []*MrkdwnNode) {
+	for _, n := range nodes {
+		switch n.Kind {
+		case MrkdwnText:
+			w.WriteString(html.EscapeString(n.Text))
+		case MrkdwnLineBreak:
+			w.WriteString("<br>")
+		case MrkdwnBold:
+			w.WriteString("<b>")
+			w.mrkdwnInline(n.Children)
+			w.WriteString("</b>")
+		case MrkdwnItalic:
+			w.WriteString("<i>")
+			w.mrkdwnInline(n.Children)
+			w.WriteString("</i>")
+		case MrkdwnStrike:
+			w.WriteString("<s>")
+			w.mrkdwnInline(n.Children)
+			w.WriteString("</s>")
+		case MrkdwnCode:
+			w.WriteString("<code>" + html.EscapeString(n.Text) + "</code>")
+		case MrkdwnLink:
+			label := n.Text
+			if label == "" {
+				label = n.URL
+			}
+			w.link(n.URL, html.EscapeString(label))
+		case MrkdwnUserMention:
+			w.mention("@", n.ID, n.Text)
+		case MrkdwnChannelMention:
+			w.mention("#", n.ID, n.Text)
+		case MrkdwnUserGroupMention:
+			w.mention("@", n.ID, n.Text)
+		case MrkdwnBroadcast:
+			w.WriteString(`<span class="broadcast">@` + html.EscapeString(n.ID) + "</span>")
+		case MrkdwnDate:
+			w.WriteString(html.EscapeString(n.Text))
+		case MrkdwnEmoji:
+			w.emoji(n.ID, "")
+		}
+	}
+}
+
+func (w *previewWriter) richText(b *slack.RichTextBlock) {
+	w.WriteString(`<div class="block rich-text">`)
+	for _, elem := range b.Elements {
+		switch e := elem.(type) {
+		case *slack.RichTextSection:
+			w.WriteString("<p>")
+			w.richTextInline(trimTrailingNewline(e.Elements))
+			w.WriteString("</p>")
+		case *slack.RichTextQuote:
+			w.WriteString("<blockquote>")
+			w.richTextInline(trimTrailingNewline(e.Elements))
+			w.WriteString("</blockquote>")
+		case *slack.RichTextPreformatted:
+			w.WriteString("<pre>" + html.EscapeString(preformattedText(e.Elements)) + "</pre>")
+		case *slack.RichTextList:
+			w.list(e)
+		}
+	}
+	w.WriteString("</div>")
+}
+
+// list writes a rich text list, indented by its indent level.
+func (w *previewWriter) list(list *slack.RichTextList) {
+	indent := max(list.Indent, 0)
+	tag, styles := "ul", previewBulletStyles
+	if list.Style == slack.RTEListOrdered {
+		tag, styles = "ol", previewNumberStyles
+	}
+	class := ""
+	if list.Border > 0 {
+		class = ` class="border"`
+	}
+	fmt.Fprintf(w, `<%s%s style="margin-left: %dpx; list-style-type: %s"`, tag, class, indent*22, styles[indent%len(styles)])
+	if tag == "ol" && list.Offset > 0 {
+		fmt.Fprintf(w, ` start="%d"`, list.Offset+1)
+	}
+	w.WriteString(">")
+	for _, item := range list.Elements {
+		w.WriteString("<li>")
+		if section, ok := item.(*slack.RichTextSection); ok {
+			w.richTextInline(section.Elements)
+		}
+		w.WriteString("</li>")
+	}
+	w.WriteString("</" + tag + ">")
+}
+
+func (w *previewWriter) richTextInline(elements []slack.RichTextSectionElement) {
+	for _, elem := range elements {
+		switch e := elem.(type) {
+		case *slack.RichTextSectionTextElement:
+			if e.Style != nil && e.Style.Code {
+				w.styled("<code>"+html.EscapeString(e.Text)+"</code>", e.Style)
+			} else {
+				w.styled(previewText(e.Text), e.Style)
+			}
+		case *slack.RichTextSectionLinkElement:
+			label := e.Text
+			if label == "" {
+				label = e.URL
+			}
+			var sb previewWriter
+			sb.link(e.URL, previewText(label))
+			w.styled(sb.String(), e.Style)
+		case *slack.RichTextSectionUserElement:
+			w.mention("@", e.UserID, "")
+		case *slack.RichTextSectionChannelElement:
+			w.mention("#", e.ChannelID, "")
+		case *slack.RichTextSectionUserGroupElement:
+			w.mention("@", e.UsergroupID, "")
+		case *slack.RichTextSectionBroadcastElement:
+			w.WriteString(`<span class="broadcast">@` + html.EscapeString(e.Range) + "</span>")
+		case *slack.RichTextSectionEmojiElement:
+			w.emoji(e.Name, e.Unicode)
+		case *slack.RichTextSectionTeamElement:
+			w.WriteString(html.EscapeString(e.TeamID))
+		case *slack.RichTextSectionDateElement:
+			w.WriteString(html.EscapeString(dateFallback(e)))
+		case *slack.RichTextSectionColorElement:
+			value := html.EscapeString(e.Value)
+			if previewColorPattern.MatchString(e.Value) {
+				w.WriteString(`<span class="color" style="background: ` + value + `"></span>`)
+			}
+			w.WriteString(value)
+		}
+	}
+}
+
+// styled writes inline HTML inside the tags of a rich text style.
+func (w *previewWriter) styled(inner string, style *slack.RichTextSectionTextStyle) {
+	if style == nil {
+		w.WriteString(inner)
+		return
+	}
+	var opening, closing string
+	for _, tag := range []struct {
+		set  bool
+		name string
+	}{{style.Bold, "b"}, {style.Italic, "i"}, {style.Strike, "s"}} {
+		if tag.set {
+			opening += "<" + tag.name + ">"
+			closing = "</" + tag.name + ">" + closing
+		}
+	}
+	w.WriteString(opening + inner + closing)
+}
+
+func (w *previewWriter) image(b *slack.ImageBlock) {
+	w.WriteString(`<div class="block image">`)
+	if b.Title != nil && b.Title.Text != "" {
+		w.WriteString(`<div class="title">` + html.EscapeString(b.Title.Text) + "</div>")
+	}
+	w.img(b.ImageURL, b.AltText)
+	w.WriteString("</div>")
+}
+
+func (w *previewWriter) context(b *slack.ContextBlock) {
+	w.WriteString(`<div class="block context">`)
+	for _, elem := range b.ContextElements.Elements {
+		switch e := elem.(type) {
+		case *slack.TextBlockObject:
+			w.WriteString("<span>")
+			if e.Type == slack.MarkdownType {
+				for _, n := range ParseMrkdwn(e.Text).Children {
+					w.mrkdwnInline(n.Children)
+				}
+			} else {
+				w.WriteString(previewText(e.Text))
+			}
+			w.WriteString("</span>")
+		case *slack.ImageBlockElement:
+			if e.ImageURL != nil {
+				w.img(*e.ImageURL, e.AltText)
+			}
+		}
+	}
+	w.WriteString("</div>")
+}
+
+func (w *previewWriter) actions(b *slack.ActionBlock) {
+	w.WriteString(`<div class="block actions">`)
+	if b.Elements != nil {
+		for _, elem := range b.Elements.ElementSet {
+			if button, ok := elem.(*slack.ButtonBlockElement); ok {
+				w.button(button)
+			} else {
+				fmt.Fprintf(w, `<span class="unsupported">%s</span>`, html.EscapeString(string(elem.ElementType())))
+			}
+		}
+	}
+	w.WriteString("</div>")
+}
+
+func (w *previewWriter) button(e *slack.ButtonBlockElement) {
+	class := "button"
+	if e.Style != "" {
+		class += " " + html.EscapeString(string(e.Style))
+	}
+	text := ""
+	if e.Text != nil {
+		text = html.EscapeString(e.Text.Text)
+	}
+	if e.URL != "" && isAllowedHTMLLink(e.URL) {
+		fmt.Fprintf(w, `<a class="%s" href="%s">%s</a>`, class, html.EscapeString(e.URL), text)
+		return
+	}
+	fmt.Fprintf(w, `<span class="%s">%s</span>`, class, text)
+}
+
+func (w *previewWriter) table(b *slack.TableBlock) {
+	w.WriteString(`<table class="block">`)
+	for i, row := range b.Rows {
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+		}
+		w.WriteString("<tr>")
+		for j, rt := range row {
+			align := "left"
+			if j < len(b.ColumnSettings) && b.ColumnSettings[j].Align != "" {
+				align = string(b.ColumnSettings[j].Align)
+			}
+			fmt.Fprintf(w, `<%s style="text-align: %s">`, cell, html.EscapeString(align))
+			if rt != nil {
+				for _, elem := range rt.Elements {
+					if section, ok := elem.(*slack.RichTextSection); ok {
+						w.richTextInline(section.Elements)
+					}
+				}
+			}
+			w.WriteString("</" + cell + ">")
+		}
+		w.WriteString("</tr>")
+	}
+	w.WriteString("</table>")
+}
+
+func (w *previewWriter) img(url, alt string) {
+	fmt.Fprintf(w, `<img src="%s" alt="%s">`, html.EscapeString(url), html.EscapeString(alt))
+}
+
+// link writes a link with an escaped label. URLs Slack would not open are written
+// as their label.
+func (w *previewWriter) link(url, label string) {
+	if !isAllowedHTMLLink(url) {
+		w.WriteString(label)
+		return
+	}
+	fmt.Fprintf(w, `<a href="%s">%s</a>`, html.EscapeString(url), label)
+}
+
+func (w *previewWriter) mention(prefix, id, label string) {
+	if label == "" {
+		label = id
+	}
+	w.WriteString(`<span class="mention">` + prefix + html.EscapeString(label) + "</span>")
+}
+
+// emoji writes an emoji as its characters, or as its shortcode when its code
+// points are missing or invalid.
+func (w *previewWriter) emoji(name, unicode string) {
+	if text, ok := emojiFromUnicode(unicode); ok {
+		w.WriteString(html.EscapeString(text))
+		return
+	}
+	w.WriteString(`<span class="emoji">:` + html.EscapeString(name) + ":</span>")
+}
+
+// emojiFromUnicode decodes an emoji's code points in hex, such as "1f44d-1f3fb",
+// and reports false when unicode is empty or invalid.
+func emojiFromUnicode(unicode string) (string, bool) {
+	if unicode == "" {
+		return "", false
+	}
+	var sb strings.Builder
+	for _, part := range strings.Split(unicode, "-") {
+		r, err := strconv.ParseUint(part, 16, 32)
+		if err != nil {
+			return "", false
+		}
+		sb.WriteRune(rune(r))
+	}
+	return sb.String(), true
+}
+
+// previewText escapes text and turns its newlines into line breaks.
+func previewText(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
+
+// trimTrailingNewline drops the newline that sections typed in Slack end with.
+func trimTrailingNewline(elements []slack.RichTextSectionElement) []slack.RichTextSectionElement {
+	if len(elements) == 0 {
+		return elements
+	}
+	last, ok := elements[len(elements)-1].(*slack.RichTextSectionTextElement)
+	if !ok || !strings.HasSuffix(last.Text, "\n") {
+		return elements
+	}
+	trimmed := *last
+	trimmed.Text = strings.TrimSuffix(last.Text, "\n")
+	return append(elements[:len(elements)-1:len(elements)-1], &trimmed)
+}
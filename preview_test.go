@@ -0,0 +1,136 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestRenderBlocksToHTML(t *testing.T) {
+	imageURL := "https://example.com/a.png"
+	tests := []struct {
+		name   string
+		blocks []slack.Block
+		want   []string
+	}{
+		{
+			name: "header and section",
+			blocks: []slack.Block{
+				slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Release <1.2>", false, false)),
+				slack.NewSectionBlock(
+					slack.NewTextBlockObject(slack.MarkdownType, "*Bold* _it_ ~gone~ `x<y` <https://example.com|docs> <@U1> <!here> :tada:\n> quoted", false, false),
+					[]*slack.TextBlockObject{slack.NewTextBlockObject(slack.PlainTextType, "field\nline", false, false)},
+					slack.NewAccessory(slack.NewImageBlockElement(imageURL, "thumb")),
+				),
+			},
+			want: []string{
+				`<h1 class="block header">Release &lt;1.2&gt;</h1>`,
+				`<p><b>Bold</b> <i>it</i> <s>gone</s> <code>x&lt;y</code> <a href="https://example.com">docs</a> <span class="mention">@U1</span> <span class="broadcast">@here</span> <span class="emoji">:tada:</span></p><blockquote><p>quoted</p></blockquote>`,
+				`<div class="fields"><div><p>field<br>line</p></div></div>`,
+				`<div class="accessory"><img src="https://example.com/a.png" alt="thumb"></div>`,
+			},
+		},
+		{
+			name: "rich text",
+			blocks: []slack.Block{
+				slack.NewRichTextBlock("",
+					slack.NewRichTextSection(
+						slack.NewRichTextSectionTextElement("bold ", &slack.RichTextSectionTextStyle{Bold: true, Italic: true}),
+						slack.NewRichTextSectionEmojiElement("thumbsup", 0, nil),
+						&slack.RichTextSectionEmojiElement{Type: slack.RTSEEmoji, Name: "tada", Unicode: "1f389"},
+						slack.NewRichTextSectionLinkElement("javascript:alert(1)", "bad", nil),
+						slack.NewRichTextSectionTextElement("\n", nil),
+					),
+					&slack.RichTextList{Type: slack.RTEList, Style: slack.RTEListOrdered, Indent: 1, Offset: 2, Elements: []slack.RichTextElement{
+						slack.NewRichTextSection(slack.NewRichTextSectionTextElement("third", nil)),
+					}},
+					&slack.RichTextQuote{Type: slack.RTEQuote, Elements: []slack.RichTextSectionElement{
+						slack.NewRichTextSectionTextElement("quote\n", nil),
+					}},
+					&slack.RichTextPreformatted{RichTextSection: slack.RichTextSection{Type: slack.RTEPreformatted, Elements: []slack.RichTextSectionElement{
+						slack.NewRichTextSectionTextElement("if a < b {}", nil),
+					}}},
+				),
+			},
+			want: []string{
+				`<p><b><i>bold </i></b><span class="emoji">:thumbsup:</span>🎉bad</p>`,
+				`<ol style="margin-left: 22px; list-style-type: lower-alpha" start="3"><li>third</li></ol>`,
+				`<blockquote>quote</blockquote>`,
+				`<pre>if a &lt; b {}</pre>`,
+			},
+		},
+		{
+			name: "divider, image, context and actions",
+			blocks: []slack.Block{
+				slack.NewDividerBlock(),
+				slack.NewImageBlock(imageURL, "diagram", "", slack.NewTextBlockObject(slack.PlainTextType, "Diagram", false, false)),
+				slack.NewContextBlock("",
+					slack.NewImageBlockElement(imageURL, "avatar"),
+					slack.NewTextBlockObject(slack.MarkdownType, "by *me*", false, false),
+				),
+				slack.NewActionBlock("",
+					slack.NewButtonBlockElement("ok", "", slack.NewTextBlockObject(slack.PlainTextType, "Approve", false, false)).WithStyle(slack.StylePrimary),
+					slack.NewButtonBlockElement("", "", slack.NewTextBlockObject(slack.PlainTextType, "Open", false, false)).WithURL("https://example.com"),
+				),
+				slack.NewInputBlock("", slack.NewTextBlockObject(slack.PlainTextType, "Name", false, false), nil, slack.NewPlainTextInputBlockElement(nil, "name")),
+			},
+			want: []string{
+				`<hr class="block divider">`,
+				`<div class="block image"><div class="title">Diagram</div><img src="https://example.com/a.png" alt="diagram"></div>`,
+				`<div class="block context"><img src="https://example.com/a.png" alt="avatar"><span>by <b>me</b></span></div>`,
+				`<span class="button primary">Approve</span><a class="button" href="https://example.com">Open</a>`,
+				`<div class="block unsupported">input block</div>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderBlocksToHTML(tt.blocks)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("RenderBlocksToHTML() does not contain %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderBlocksToHTMLFromMarkdown(t *testing.T) {
+	blocks, err := NewConverter(WithExtensions(extension.GFM)).ConvertMarkdownTextToBlocks(
+		"# Title\n\n- one\n  - nested\n\n| a | b |\n| :-: | --: |\n| 1 | 2 |\n\n<script>alert(1)</script>\n\ntext <b>",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := RenderBlocksToHTML(blocks)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<style>",
+		`<ul style="margin-left: 0px; list-style-type: disc"><li>one</li></ul><ul style="margin-left: 22px; list-style-type: circle"><li>nested</li></ul>`,
+		`<tr><th style="text-align: center"><b>a</b></th><th style="text-align: right"><b>b</b></th></tr>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderBlocksToHTML() does not contain %q:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"<script", "<link"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("RenderBlocksToHTML() contains %q:\n%s", unwanted, got)
+		}
+	}
+}
+
+func TestBlockKitBuilderURL(t *testing.T) {
+	got, err := BlockKitBuilderURL([]slack.Block{slack.NewDividerBlock()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "https://app.slack.com/block-kit-builder/#%7B%22blocks%22:%5B%7B%22type%22:%22divider%22%7D%5D%7D"
+	if got != want {
+		t.Errorf("BlockKitBuilderURL() = %q, want %q", got, want)
+	}
+}
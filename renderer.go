@@ -0,0 +1,76 @@
+package util
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+)
+
+// RenderFormat controls what a Renderer writes.
+type RenderFormat int
+
+const (
+	// RenderFormatBlocks writes the JSON array of blocks.
+	RenderFormatBlocks RenderFormat = iota
+	// RenderFormatPayload writes a chat.postMessage payload with the fallback text and the blocks.
+	RenderFormatPayload
+)
+
+// Payload is the JSON body written by a Renderer in RenderFormatPayload.
+type Payload struct {
+	Text   string        `json:"text"`
+	Blocks []slack.Block `json:"blocks"`
+}
+
+// Renderer is a goldmark renderer that writes Block Kit JSON, so that
+//
+//	md := goldmark.New(goldmark.WithRenderer(util.NewRenderer()))
+//	err := md.Convert(source, w)
+//
+// writes the blocks of source to w. Parsing is left to the goldmark instance,
+// so the Converter's extension and parser options do not apply.
+type Renderer struct {
+	converter *Converter
+	format    RenderFormat
+}
+
+// NewRenderer creates a Renderer that renders with a Converter created with the given options.
+func NewRenderer(opts ...Option) *Renderer {
+	return NewConverter(opts...).Renderer()
+}
+
+// Renderer returns a goldmark renderer that renders with c.
+func (c *Converter) Renderer() *Renderer {
+	return &Renderer{converter: c, format: c.renderFormat}
+}
+
+// WithRenderFormat sets what a Renderer created from the Converter writes.
+// The default is RenderFormatBlocks.
+func WithRenderFormat(format RenderFormat) Option {
+	return func(c *Converter) {
+		c.renderFormat = format
+	}
+}
+
+// Render writes the Block Kit JSON of n, followed by a newline, to w.
+func (r *Renderer) Render(w io.Writer, source []byte, n ast.Node) error {
+	blocks, err := r.converter.ConvertNodeToBlocks(n, source)
+	if err != nil {
+		return err
+	}
+
+	var v any = blocks
+	if r.format == RenderFormatPayload {
+		v = Payload{Text: r.converter.ConvertNodeToFallbackText(n, source), Blocks: blocks}
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+// AddOptions implements renderer.Renderer. Renderer options configure HTML
+// rendering and are ignored; configure the Converter instead.
+func (r *Renderer) AddOptions(...renderer.Option) {}
+
+var _ renderer.Renderer = (*Renderer)(nil)
@@ -0,0 +1,91 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		markdown  string
+		wantTypes []slack.MessageBlockType
+	}{
+		{
+			name:      "blocks",
+			markdown:  "# Title\n\nText\n\n- item",
+			wantTypes: []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection, slack.MBTRichText},
+		},
+		{
+			name:      "converter options",
+			opts:      []Option{WithHeadingStyle(HeadingStyleBold)},
+			markdown:  "# Title",
+			wantTypes: []slack.MessageBlockType{slack.MBTSection},
+		},
+		{
+			name:      "empty",
+			markdown:  "",
+			wantTypes: []slack.MessageBlockType{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := goldmark.New(goldmark.WithRenderer(NewRenderer(tt.opts...)))
+
+			var buf bytes.Buffer
+			if err := md.Convert([]byte(tt.markdown), &buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got slack.Blocks
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("output is not a block array: %v, output=%s", err, buf.String())
+			}
+			if len(got.BlockSet) != len(tt.wantTypes) {
+				t.Fatalf("block count mismatch, got=%v, want=%v", len(got.BlockSet), len(tt.wantTypes))
+			}
+			for i, block := range got.BlockSet {
+				if block.BlockType() != tt.wantTypes[i] {
+					t.Errorf("block type mismatch at index=%d, got=%v, want=%v", i, block.BlockType(), tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRendererPayload(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.Strikethrough),
+		goldmark.WithRenderer(NewRenderer(WithRenderFormat(RenderFormatPayload))),
+	)
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte("# Deploy\n\n~~failed~~ **done**"), &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got struct {
+		Text   string       `json:"text"`
+		Blocks slack.Blocks `json:"blocks"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a payload: %v, output=%s", err, buf.String())
+	}
+
+	if got.Text != "Deploy\nfailed done" {
+		t.Errorf("text mismatch, got=%q", got.Text)
+	}
+	if len(got.Blocks.BlockSet) != 2 {
+		t.Fatalf("block count mismatch, got=%v, want=%v", len(got.Blocks.BlockSet), 2)
+	}
+	if text := got.Blocks.BlockSet[1].(*slack.SectionBlock).Text.Text; text != "~failed~ *done*" {
+		t.Errorf("section text mismatch, got=%q", text)
+	}
+}
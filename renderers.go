@@ -0,0 +1,162 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+)
+
+// BlockRenderer renders a block level markdown node, including its children, to slack blocks.
+type BlockRenderer func(ctx *RenderContext, n ast.Node) ([]slack.Block, error)
+
+// InlineRenderer renders an inline markdown node, including its children, to rich text
+// elements. style holds the styling of the enclosing emphasis and is nil when there is none.
+// In section text the returned elements are written as mrkdwn.
+type InlineRenderer func(ctx *RenderContext, n ast.Node, style *slack.RichTextSectionTextStyle) []slack.RichTextSectionElement
+
+// RenderContext gives renderers access to the source and to the converter's rendering
+// of other nodes, so that a renderer can delegate its children back to the converter.
+type RenderContext struct {
+	converter *Converter
+	context   context.Context
+	source    []byte
+}
+
+// WithBlockRenderer registers a renderer for block level nodes of the given kind,
+// replacing the default rendering of that kind. Nodes of kinds that have neither a
+// registered nor a default renderer are not rendered themselves, but their children are.
+func WithBlockRenderer(kind ast.NodeKind, r BlockRenderer) Option {
+	return func(c *Converter) {
+		if c.blockRenderers == nil {
+			c.blockRenderers = map[ast.NodeKind]BlockRenderer{}
+		}
+		c.blockRenderers[kind] = r
+	}
+}
+
+// WithInlineRenderer registers a renderer for inline nodes of the given kind,
+// replacing the default rendering of that kind.
+func WithInlineRenderer(kind ast.NodeKind, r InlineRenderer) Option {
+	return func(c *Converter) {
+		if c.inlineRenderers == nil {
+			c.inlineRenderers = map[ast.NodeKind]InlineRenderer{}
+		}
+		c.inlineRenderers[kind] = r
+	}
+}
+
+func (c *Converter) newRenderContext(ctx context.Context, source []byte) *RenderContext {
+	return &RenderContext{converter: c, context: ctx, source: source}
+}
+
+// Context returns the context of the conversion.
+func (ctx *RenderContext) Context() context.Context {
+	return ctx.context
+}
+
+// Source returns the markdown source the nodes' segments refer to.
+func (ctx *RenderContext) Source() []byte {
+	return ctx.source
+}
+
+// RenderChildren renders the block level children of n with the registered and default renderers.
+func (ctx *RenderContext) RenderChildren(n ast.Node) ([]slack.Block, error) {
+	var blocks []slack.Block
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		rendered, err := ctx.converter.renderBlocks(ctx.context, child, ctx.source, false)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, rendered...)
+	}
+	return blocks, nil
+}
+
+// RenderDefault renders a block level node with the default renderer of its kind,
+// ignoring any registered renderer. It renders the children of nodes without a
+// default renderer.
+func (ctx *RenderContext) RenderDefault(n ast.Node) ([]slack.Block, error) {
+	blocks, status := ctx.converter.renderDefaultBlock(n, ctx.source)
+	if status == ast.WalkSkipChildren {
+		return blocks, nil
+	}
+	return ctx.RenderChildren(n)
+}
+
+// RenderInline renders the inline children of n to rich text elements, styled with
+// the style of the enclosing emphasis, which may be nil.
+func (ctx *RenderContext) RenderInline(n ast.Node, style *slack.RichTextSectionTextStyle) []slack.RichTextSectionElement {
+	bold := style != nil && style.Bold
+	italic := style != nil && style.Italic
+
+	var elements []slack.RichTextSectionElement
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		elements = append(elements, ctx.converter.parseStyledInlineElements(child, ctx.source, bold, italic)...)
+	}
+	return elements
+}
+
+// RenderMrkdwn renders the inline children of n to mrkdwn text.
+func (ctx *RenderContext) RenderMrkdwn(n ast.Node) string {
+	mrkdwn, _ := ctx.converter.renderMrkdwn(n, ctx.source)
+	return mrkdwn
+}
+
+// richTextElementsToMrkdwn writes rich text elements as mrkdwn text.
+func richTextElementsToMrkdwn(elements []slack.RichTextSectionElement) string {
+	var sb strings.Builder
+	for _, elem := range elements {
+		switch e := elem.(type) {
+		case *slack.RichTextSectionTextElement:
+			sb.WriteString(styleMrkdwn(e.Text, e.Style))
+		case *slack.RichTextSectionLinkElement:
+			link := "<" + e.URL + ">"
+			if e.Text != "" {
+				link = fmt.Sprintf("<%s|%s>", e.URL, e.Text)
+			}
+			sb.WriteString(styleMrkdwn(link, e.Style))
+		case *slack.RichTextSectionUserElement:
+			sb.WriteString("<@" + e.UserID + ">")
+		case *slack.RichTextSectionChannelElement:
+			sb.WriteString("<#" + e.ChannelID + ">")
+		case *slack.RichTextSectionUserGroupElement:
+			sb.WriteString("<!subteam^" + e.UsergroupID + ">")
+		case *slack.RichTextSectionBroadcastElement:
+			sb.WriteString("<!" + e.Range + ">")
+		case *slack.RichTextSectionEmojiElement:
+			sb.WriteString(":" + e.Name + ":")
+		case *slack.RichTextSectionDateElement:
+			fallback := ""
+			if e.Fallback != nil {
+				fallback = *e.Fallback
+			}
+			sb.WriteString(fmt.Sprintf("<!date^%d^%s|%s>", int64(e.Timestamp), e.Format, fallback))
+		case *slack.RichTextSectionColorElement:
+			sb.WriteString(e.Value)
+		}
+	}
+	return sb.String()
+}
+
+// styleMrkdwn wraps text in the mrkdwn markers of a rich text style.
+func styleMrkdwn(text string, style *slack.RichTextSectionTextStyle) string {
+	if style == nil || text == "" {
+		return text
+	}
+	if style.Code {
+		text = "`" + text + "`"
+	}
+	if style.Strike {
+		text = "~" + text + "~"
+	}
+	if style.Italic {
+		text = "_" + text + "_"
+	}
+	if style.Bold {
+		text = "*" + text + "*"
+	}
+	return text
+}
@@ -0,0 +1,180 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	gutil "github.com/yuin/goldmark/util"
+)
+
+// admonition is a ":::warning" container used to test custom node rendering.
+type admonition struct {
+	ast.BaseBlock
+	name string
+}
+
+var kindAdmonition = ast.NewNodeKind("Admonition")
+
+func (n *admonition) Kind() ast.NodeKind {
+	return kindAdmonition
+}
+
+func (n *admonition) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Name": n.name}, nil)
+}
+
+type admonitionParser struct{}
+
+func (p *admonitionParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+func (p *admonitionParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	if !bytes.HasPrefix(line, []byte(":::")) {
+		return nil, parser.NoChildren
+	}
+	name := string(bytes.TrimSpace(line[3:]))
+	if name == "" {
+		return nil, parser.NoChildren
+	}
+	reader.AdvanceLine()
+	return &admonition{name: name}, parser.HasChildren
+}
+
+func (p *admonitionParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	line, _ := reader.PeekLine()
+	if string(bytes.TrimSpace(line)) == ":::" {
+		reader.AdvanceLine()
+		return parser.Close
+	}
+	return parser.Continue | parser.HasChildren
+}
+
+func (p *admonitionParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {}
+
+func (p *admonitionParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p *admonitionParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+type admonitionExtension struct{}
+
+func (e admonitionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithBlockParsers(gutil.Prioritized(&admonitionParser{}, 100)))
+}
+
+func TestConverterBlockRenderer(t *testing.T) {
+	c := NewConverter(
+		WithExtensions(admonitionExtension{}),
+		WithBlockRenderer(kindAdmonition, func(ctx *RenderContext, n ast.Node) ([]slack.Block, error) {
+			children, err := ctx.RenderChildren(n)
+			if err != nil {
+				return nil, err
+			}
+			title := slack.NewTextBlockObject(slack.MarkdownType, ":"+n.(*admonition).name+": *Note*", false, false)
+			return append([]slack.Block{slack.NewContextBlock("", title)}, children...), nil
+		}),
+	)
+
+	got, err := c.ConvertMarkdownTextToBlocks("Before\n\n:::warning\nBe **careful**.\n\n- one\n:::\n\nAfter")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantTypes := []slack.MessageBlockType{
+		slack.MBTSection, slack.MBTContext, slack.MBTSection, slack.MBTRichText, slack.MBTSection,
+	}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("block count mismatch, got=%v, want=%v", len(got), len(wantTypes))
+	}
+	for i := range got {
+		if got[i].BlockType() != wantTypes[i] {
+			t.Errorf("block type mismatch at index=%d, got=%v, want=%v", i, got[i].BlockType(), wantTypes[i])
+		}
+	}
+
+	context := got[1].(*slack.ContextBlock)
+	if text := context.ContextElements.Elements[0].(*slack.TextBlockObject).Text; text != ":warning: *Note*" {
+		t.Errorf("context text mismatch, got=%q", text)
+	}
+	if text := got[2].(*slack.SectionBlock).Text.Text; text != "Be *careful*." {
+		t.Errorf("child section text mismatch, got=%q", text)
+	}
+}
+
+func TestConverterBlockRendererDefault(t *testing.T) {
+	c := NewConverter(
+		WithBlockRenderer(ast.KindHeading, func(ctx *RenderContext, n ast.Node) ([]slack.Block, error) {
+			blocks, err := ctx.RenderDefault(n)
+			if err != nil {
+				return nil, err
+			}
+			return append(blocks, slack.NewDividerBlock()), nil
+		}),
+	)
+
+	got, err := c.ConvertMarkdownTextToBlocks("# Title\n\nText")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantTypes := []slack.MessageBlockType{slack.MBTHeader, slack.MBTDivider, slack.MBTSection}
+	if len(got) != len(wantTypes) {
+		t.Fatalf("block count mismatch, got=%v, want=%v", len(got), len(wantTypes))
+	}
+	for i := range got {
+		if got[i].BlockType() != wantTypes[i] {
+			t.Errorf("block type mismatch at index=%d, got=%v, want=%v", i, got[i].BlockType(), wantTypes[i])
+		}
+	}
+}
+
+func TestConverterInlineRenderer(t *testing.T) {
+	c := NewConverter(
+		WithInlineRenderer(ast.KindLink, func(ctx *RenderContext, n ast.Node, style *slack.RichTextSectionTextStyle) []slack.RichTextSectionElement {
+			link := n.(*ast.Link)
+			elements := ctx.RenderInline(n, style)
+			return append(elements, &slack.RichTextSectionTextElement{
+				Type:  slack.RTSEText,
+				Text:  " (" + string(link.Destination) + ")",
+				Style: style,
+			})
+		}),
+	)
+
+	got, err := c.ConvertMarkdownTextToBlocks("See **[docs](https://example.com)**\n\n- **[docs](https://example.com)**")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("block count mismatch, got=%v, want=%v", len(got), 2)
+	}
+
+	if text := got[0].(*slack.SectionBlock).Text.Text; text != "See *docs (https://example.com)*" {
+		t.Errorf("section text mismatch, got=%q", text)
+	}
+
+	list := got[1].(*slack.RichTextBlock).Elements[0].(*slack.RichTextList)
+	elements := list.Elements[0].(*slack.RichTextSection).Elements
+	if len(elements) != 2 {
+		t.Fatalf("list item element count mismatch, got=%v, want=%v", len(elements), 2)
+	}
+	for i, elem := range elements {
+		textElem := elem.(*slack.RichTextSectionTextElement)
+		if textElem.Style == nil || !textElem.Style.Bold {
+			t.Errorf("list item element not bold at index=%d", i)
+		}
+	}
+	if text := elements[1].(*slack.RichTextSectionTextElement).Text; text != " (https://example.com)" {
+		t.Errorf("list item text mismatch, got=%q", text)
+	}
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"github.com/slack-go/slack"
+)
+
+const (
+	// MaxMessageBlocks is the maximum number of blocks Slack accepts in a single message.
+	MaxMessageBlocks = 50
+	// MaxSurfaceBlocks is the maximum number of blocks Slack accepts in a modal or home tab.
+	MaxSurfaceBlocks = 100
+)
+
+// SplitOption configures how blocks are split into chunks.
+type SplitOption func(*splitConfig)
+
+type splitConfig struct {
+	maxBlocks int
+}
+
+// WithMaxBlocks sets the maximum number of blocks per chunk.
+// Use MaxMessageBlocks for messages and MaxSurfaceBlocks for modals and home tabs.
+func WithMaxBlocks(n int) SplitOption {
+	return func(c *splitConfig) {
+		if n > 0 {
+			c.maxBlocks = n
+		}
+	}
+}
+
+// ConvertMarkdownTextToBlockChunks converts a markdown text to slack blocks
+// split into chunks that each fit in a single message.
+func ConvertMarkdownTextToBlockChunks(markdown string, opts ...SplitOption) ([][]slack.Block, error) {
+	blocks, err := ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		return nil, err
+	}
+	return SplitBlocks(blocks, opts...), nil
+}
+
+// ConvertMarkdownTextToBlockChunks converts a markdown text to slack blocks split
+// into chunks of at most the converter's blocks per message.
+func (c *Converter) ConvertMarkdownTextToBlockChunks(markdown string) ([][]slack.Block, error) {
+	blocks, err := c.ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		return nil, err
+	}
+	return SplitBlocks(blocks, WithMaxBlocks(c.maxBlocks)), nil
+}
+
+// SplitBlocks splits blocks into chunks of at most MaxMessageBlocks blocks (or the
+// limit given by WithMaxBlocks). When a chunk has to be cut, it is cut before the
+// last heading or divider that fits so that sections stay together. Lists are
+// rendered as a single rich_text block and are therefore never split. Every table
+// after the first of a chunk starts a new chunk, since a message holds one table.
+func SplitBlocks(blocks []slack.Block, opts ...SplitOption) [][]slack.Block {
+	cfg := splitConfig{maxBlocks: MaxMessageBlocks}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var chunks [][]slack.Block
+	for start := 0; start < len(blocks); {
+		end := min(start+cfg.maxBlocks, len(blocks))
+		if table := secondTable(blocks[start:end]); table > 0 {
+			// A message holds at most one table
+			end = start + table
+		} else if end == len(blocks) {
+			chunks = append(chunks, blocks[start:end:end])
+			break
+		} else {
+			// Prefer cutting before a heading or divider, scanning back from the limit
+			for i := end; i > start; i-- {
+				if isSplitBoundary(blocks[i]) {
+					end = i
+					break
+				}
+			}
+		}
+
+		chunks = append(chunks, blocks[start:end:end])
+		start = end
+	}
+
+	return chunks
+}
+
+// secondTable returns the index of the second table block of blocks, or 0 if there is none.
+func secondTable(blocks []slack.Block) int {
+	seen := false
+	for i, block := range blocks {
+		if block != nil && block.BlockType() == slack.MBTTable {
+			if seen {
+				return i
+			}
+			seen = true
+		}
+	}
+	return 0
+}
+
+// isSplitBoundary reports whether a chunk may preferably start at the block.
+func isSplitBoundary(block slack.Block) bool {
+	switch block.BlockType() {
+	case slack.MBTHeader, slack.MBTDivider:
+		return true
+	}
+	return false
+}
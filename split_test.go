@@ -0,0 +1,205 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/extension"
+)
+
+func newTestSections(n int) []slack.Block {
+	blocks := make([]slack.Block, 0, n)
+	for i := 0; i < n; i++ {
+		blocks = append(blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, "text", false, false), nil, nil,
+		))
+	}
+	return blocks
+}
+
+func TestSplitBlocks(t *testing.T) {
+	header := slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Title", true, false))
+	divider := slack.NewDividerBlock()
+
+	withBoundary := func(n, at int, b slack.Block) []slack.Block {
+		blocks := newTestSections(n)
+		blocks[at] = b
+		return blocks
+	}
+	withTables := func(n int, at ...int) []slack.Block {
+		blocks := newTestSections(n)
+		for _, i := range at {
+			blocks[i] = &slack.TableBlock{Type: slack.MBTTable}
+		}
+		return blocks
+	}
+
+	tests := []struct {
+		name       string
+		blocks     []slack.Block
+		opts       []SplitOption
+		wantSizes  []int
+		wantFirsts []slack.MessageBlockType
+	}{
+		{
+			name:      "empty",
+			blocks:    nil,
+			wantSizes: nil,
+		},
+		{
+			name:      "under limit",
+			blocks:    newTestSections(10),
+			wantSizes: []int{10},
+		},
+		{
+			name:      "exactly at limit",
+			blocks:    newTestSections(50),
+			wantSizes: []int{50},
+		},
+		{
+			name:      "over limit without boundary",
+			blocks:    newTestSections(120),
+			wantSizes: []int{50, 50, 20},
+		},
+		{
+			name:       "cut before heading",
+			blocks:     withBoundary(60, 40, header),
+			wantSizes:  []int{40, 20},
+			wantFirsts: []slack.MessageBlockType{slack.MBTSection, slack.MBTHeader},
+		},
+		{
+			name:       "cut before divider",
+			blocks:     withBoundary(60, 30, divider),
+			wantSizes:  []int{30, 30},
+			wantFirsts: []slack.MessageBlockType{slack.MBTSection, slack.MBTDivider},
+		},
+		{
+			name:       "heading right at limit",
+			blocks:     withBoundary(60, 50, header),
+			wantSizes:  []int{50, 10},
+			wantFirsts: []slack.MessageBlockType{slack.MBTSection, slack.MBTHeader},
+		},
+		{
+			name:      "heading at start is not a cut",
+			blocks:    withBoundary(60, 0, header),
+			wantSizes: []int{50, 10},
+		},
+		{
+			name:       "cut before second table",
+			blocks:     withTables(10, 2, 6),
+			wantSizes:  []int{6, 4},
+			wantFirsts: []slack.MessageBlockType{slack.MBTSection, slack.MBTTable},
+		},
+		{
+			name:      "surface limit",
+			blocks:    newTestSections(120),
+			opts:      []SplitOption{WithMaxBlocks(MaxSurfaceBlocks)},
+			wantSizes: []int{100, 20},
+		},
+		{
+			name:      "custom limit",
+			blocks:    newTestSections(7),
+			opts:      []SplitOption{WithMaxBlocks(3)},
+			wantSizes: []int{3, 3, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitBlocks(tt.blocks, tt.opts...)
+
+			if len(got) != len(tt.wantSizes) {
+				t.Fatalf("chunk count mismatch, got=%v, want=%v", len(got), len(tt.wantSizes))
+			}
+
+			for i, chunk := range got {
+				if len(chunk) != tt.wantSizes[i] {
+					t.Errorf("chunk size mismatch at index=%d, got=%v, want=%v", i, len(chunk), tt.wantSizes[i])
+				}
+				if tt.wantFirsts != nil && chunk[0].BlockType() != tt.wantFirsts[i] {
+					t.Errorf("first block type mismatch at index=%d, got=%v, want=%v",
+						i, chunk[0].BlockType(), tt.wantFirsts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestConvertMarkdownTextToBlockChunks(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 3; i++ {
+		sb.WriteString("# Heading\n\n")
+		for j := 0; j < 30; j++ {
+			sb.WriteString("Paragraph\n\n")
+		}
+	}
+
+	got, err := ConvertMarkdownTextToBlockChunks(sb.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantSizes := []int{31, 31, 31}
+	if len(got) != len(wantSizes) {
+		t.Fatalf("chunk count mismatch, got=%v, want=%v", len(got), len(wantSizes))
+	}
+	for i, chunk := range got {
+		if len(chunk) != wantSizes[i] {
+			t.Errorf("chunk size mismatch at index=%d, got=%v, want=%v", i, len(chunk), wantSizes[i])
+		}
+		if chunk[0].BlockType() != slack.MBTHeader {
+			t.Errorf("chunk at index=%d does not start with a header, got=%v", i, chunk[0].BlockType())
+		}
+	}
+}
+
+func TestConvertMarkdownTextToBlockChunksTables(t *testing.T) {
+	c := NewConverter(WithExtensions(extension.GFM))
+
+	var long strings.Builder
+	long.WriteString("| a |\n| - |\n")
+	for i := 0; i < 150; i++ {
+		long.WriteString("| 1 |\n")
+	}
+	wide := strings.Repeat("| a ", 25) + "|\n" + strings.Repeat("| - ", 25) + "|\n" + strings.Repeat("| 1 ", 25) + "|"
+
+	tests := []struct {
+		name       string
+		markdown   string
+		wantChunks int
+	}{
+		{
+			name:       "two tables",
+			markdown:   "| a |\n| - |\n| 1 |\n\ntext\n\n| b |\n| - |\n| 2 |",
+			wantChunks: 2,
+		},
+		{
+			name:       "table with too many rows",
+			markdown:   long.String(),
+			wantChunks: 2,
+		},
+		{
+			name:       "table with too many columns",
+			markdown:   wide,
+			wantChunks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.ConvertMarkdownTextToBlockChunks(tt.markdown)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != tt.wantChunks {
+				t.Errorf("chunk count mismatch, got=%v, want=%v", len(got), tt.wantChunks)
+			}
+			for i, chunk := range got {
+				if errs := ValidateBlocks(chunk); len(errs) > 0 {
+					t.Errorf("chunk at index=%d is invalid: %v", i, errs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+// Stream converts markdown that arrives in chunks, such as the tokens streamed by an
+// LLM, so that a message can be updated as the text grows.
+//
+// Blocks that are followed by a blank line and a new top-level block are final and are
+// not rendered again. The rest of the text is rendered tolerantly: unclosed code fences
+// and emphasis are closed, and a last line holding only a list, quote or heading marker
+// is held back until its content arrives. Because final blocks are rendered on their
+// own, reference links defined after them are not resolved.
+//
+// A Stream is not safe for concurrent use.
+type Stream struct {
+	converter *Converter
+	text      []byte
+
+	// scanned is the end of the complete lines already scanned for block boundaries,
+	// fence is the marker of the code fence open at that point, blank reports whether
+	// the last scanned line was blank and list whether the last top-level block is a list.
+	scanned int
+	fence   string
+	blank   bool
+	list    bool
+
+	// frozen holds the blocks of text[:frozenEnd], which no longer change.
+	frozenEnd int
+	frozen    []slack.Block
+
+	last [][]byte
+}
+
+// StreamUpdate is the rendering of a Stream's text after a call to Append or Close.
+type StreamUpdate struct {
+	// Blocks are the blocks of all text so far.
+	Blocks []slack.Block
+	// Changed holds the indices of the blocks that were added or changed since the last update.
+	Changed []int
+	// Removed is the number of blocks at the end of the last update that no longer exist.
+	Removed int
+}
+
+// NewStream creates a Stream that converts with a Converter created with the given options.
+func NewStream(opts ...Option) *Stream {
+	return NewConverter(opts...).NewStream()
+}
+
+// NewStream creates a Stream that converts with c.
+func (c *Converter) NewStream() *Stream {
+	return &Stream{converter: c}
+}
+
+// Append adds a chunk of markdown to the stream and renders the text so far.
+func (s *Stream) Append(chunk string) (*StreamUpdate, error) {
+	s.text = append(s.text, chunk...)
+	if limit := s.converter.maxInputSize; limit > 0 && len(s.text) > limit {
+		return nil, &LimitError{Err: ErrInputTooLarge, Limit: limit}
+	}
+
+	if err := s.freeze(); err != nil {
+		return nil, err
+	}
+
+	tail, err := s.converter.ConvertMarkdownTextToBlocks(repairMarkdown(string(s.text[s.frozenEnd:])))
+	if err != nil {
+		return nil, err
+	}
+	return s.update(tail)
+}
+
+// Close renders the text so far as it is, without closing fences or emphasis.
+// Call it once the stream has ended.
+func (s *Stream) Close() (*StreamUpdate, error) {
+	tail, err := s.converter.ConvertMarkdownTextToBlocks(string(s.text[s.frozenEnd:]))
+	if err != nil {
+		return nil, err
+	}
+	return s.update(tail)
+}
+
+// Text returns the markdown appended so far.
+func (s *Stream) Text() string {
+	return string(s.text)
+}
+
+// freeze scans the new complete lines for the start of a top-level block that
+// follows a blank line, and renders the text before it as final blocks.
+func (s *Stream) freeze() error {
+	for {
+		end := bytes.IndexByte(s.text[s.scanned:], '\n')
+		if end < 0 {
+			return nil
+		}
+		start := s.scanned
+		line := string(s.text[start : start+end])
+		s.scanned = start + end + 1
+
+		if s.fence != "" {
+			if isClosingFence(line, s.fence) {
+				s.fence = ""
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			s.blank = true
+			continue
+		}
+
+		if startsWithSpace(line) {
+			s.fence = openingFence(line)
+			s.blank = false
+			continue
+		}
+		listItem := isListItemLine(line)
+		if s.blank && start > s.frozenEnd && !(listItem && s.list) {
+			blocks, err := s.converter.ConvertMarkdownTextToBlocks(string(s.text[s.frozenEnd:start]))
+			if err != nil {
+				return err
+			}
+			s.frozen = append(s.frozen, blocks...)
+			s.frozenEnd = start
+		}
+		s.fence = openingFence(line)
+		s.blank = false
+		s.list = listItem
+	}
+}
+
+// update combines the final blocks with the blocks of the tail and compares
+// them with the last update.
+func (s *Stream) update(tail []slack.Block) (*StreamUpdate, error) {
+	blocks := make([]slack.Block, 0, len(s.frozen)+len(tail))
+	blocks = append(blocks, s.frozen...)
+	blocks = append(blocks, tail...)
+	if limit := s.converter.maxOutputBlocks; limit > 0 && len(blocks) > limit {
+		return nil, &LimitError{Err: ErrTooManyBlocks, Limit: limit}
+	}
+
+	u := &StreamUpdate{Blocks: blocks}
+	encoded := make([][]byte, len(blocks))
+	for i, block := range blocks {
+		b, err := json.Marshal(block)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = b
+		if i >= len(s.last) || !bytes.Equal(s.last[i], b) {
+			u.Changed = append(u.Changed, i)
+		}
+	}
+	if len(s.last) > len(blocks) {
+		u.Removed = len(s.last) - len(blocks)
+	}
+	s.last = encoded
+
+	return u, nil
+}
+
+// pendingMarkerPattern matches an unfinished last line that holds only a marker,
+// which would briefly render as something else, such as "-" as a setext heading.
+var pendingMarkerPattern = regexp.MustCompile("^[ \\t]*(?:[-*+=#>`~]+|[0-9]+[.)]?)[ \\t]*$")
+
+// repairMarkdown makes partial markdown render as it will once complete: it holds
+// back a last line of only a marker and closes an open code fence, or else the
+// code spans and emphasis left open in the last paragraph.
+func repairMarkdown(markdown string) string {
+	lastLine := strings.LastIndexByte(markdown, '\n') + 1
+	if pendingMarkerPattern.MatchString(markdown[lastLine:]) {
+		markdown = markdown[:lastLine]
+	}
+
+	fence := ""
+	paragraph := 0
+	for start := 0; start < len(markdown); {
+		end := strings.IndexByte(markdown[start:], '\n')
+		if end < 0 {
+			end = len(markdown)
+		} else {
+			end += start
+		}
+		line := markdown[start:end]
+
+		switch {
+		case fence != "":
+			if isClosingFence(line, fence) {
+				fence = ""
+				paragraph = end + 1
+			}
+		case strings.TrimSpace(line) == "":
+			paragraph = end + 1
+		default:
+			if fence = openingFence(line); fence != "" || isBlockStartLine(line) {
+				paragraph = start
+			}
+		}
+		start = end + 1
+	}
+
+	if fence != "" {
+		if !strings.HasSuffix(markdown, "\n") {
+			markdown += "\n"
+		}
+		return markdown + fence + "\n"
+	}
+	if paragraph >= len(markdown) {
+		return markdown
+	}
+	return markdown[:paragraph] + closeInlineMarkup(markdown[paragraph:])
+}
+
+type openDelimiter struct {
+	char   byte
+	length int
+}
+
+// closeInlineMarkup appends the closing delimiters of the code span and emphasis
+// left open in paragraph, and drops a delimiter run at its end that closes nothing.
+func closeInlineMarkup(paragraph string) string {
+	var stack []openDelimiter
+	code := 0
+	dangling := -1
+
+	for i := 0; i < len(paragraph); {
+		ch := paragraph[i]
+		if ch == '\\' && code == 0 {
+			i += 2
+			continue
+		}
+		if ch != '`' && ch != '*' && ch != '_' && ch != '~' {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(paragraph) && paragraph[j] == ch {
+			j++
+		}
+		length := j - i
+
+		if ch == '`' {
+			if code == 0 {
+				code = length
+			} else if code == length {
+				code = 0
+			}
+		} else if code == 0 {
+			prev, _ := utf8.DecodeLastRuneInString(paragraph[:i])
+			next, _ := utf8.DecodeRuneInString(paragraph[j:])
+			if i == 0 {
+				prev = ' '
+			}
+			if j == len(paragraph) {
+				next = ' '
+			}
+			canOpen := !unicode.IsSpace(next)
+			canClose := !unicode.IsSpace(prev)
+			if ch == '_' && isWordRune(prev) && isWordRune(next) {
+				canOpen, canClose = false, false
+			}
+
+			switch {
+			case canClose && len(stack) > 0 && stack[len(stack)-1].char == ch:
+				stack = stack[:len(stack)-1]
+			case canOpen:
+				stack = append(stack, openDelimiter{char: ch, length: length})
+			case j == len(strings.TrimRight(paragraph, " \t\n")):
+				dangling = i
+			}
+		}
+		i = j
+	}
+
+	if code > 0 {
+		return strings.TrimRight(paragraph, "\n") + strings.Repeat("`", code) + closeDelimiters(stack)
+	}
+	if dangling >= 0 {
+		paragraph = paragraph[:dangling]
+	}
+	if len(stack) == 0 {
+		return paragraph
+	}
+	return strings.TrimRight(paragraph, " \t\n") + closeDelimiters(stack)
+}
+
+func closeDelimiters(stack []openDelimiter) string {
+	var sb strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		sb.WriteString(strings.Repeat(string(stack[i].char), stack[i].length))
+	}
+	return sb.String()
+}
+
+// openingFence returns the marker of the code fence line opens, or "" if it opens none.
+func openingFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == trimmed[0] {
+		n++
+	}
+	if n < 3 || (trimmed[0] == '`' && strings.Contains(trimmed[n:], "`")) {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// isClosingFence reports whether line closes the code fence opened with fence.
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+// isListItemLine reports whether line starts with a bullet or ordered list marker.
+func isListItemLine(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" {
+		return false
+	}
+	marker := 0
+	switch trimmed[0] {
+	case '-', '*', '+':
+		marker = 1
+	default:
+		for marker < len(trimmed) && marker < 9 && trimmed[marker] >= '0' && trimmed[marker] <= '9' {
+			marker++
+		}
+		if marker == 0 || marker >= len(trimmed) || (trimmed[marker] != '.' && trimmed[marker] != ')') {
+			return false
+		}
+		marker++
+	}
+	return marker == len(trimmed) || trimmed[marker] == ' ' || trimmed[marker] == '\t'
+}
+
+// isBlockStartLine reports whether line starts a list item, quote or heading, whose
+// inline content cannot continue the paragraph before it.
+func isBlockStartLine(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	return isListItemLine(line) || strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "#")
+}
+
+func startsWithSpace(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
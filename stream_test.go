@@ -0,0 +1,205 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestRepairMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			name:     "complete text",
+			markdown: "Some **bold** text",
+			want:     "Some **bold** text",
+		},
+		{
+			name:     "unclosed bold",
+			markdown: "Some **bol",
+			want:     "Some **bol**",
+		},
+		{
+			name:     "unclosed nested emphasis",
+			markdown: "Some **bold _ital ",
+			want:     "Some **bold _ital_**",
+		},
+		{
+			name:     "dangling delimiter",
+			markdown: "Some text **",
+			want:     "Some text ",
+		},
+		{
+			name:     "unclosed code span",
+			markdown: "Run `go te",
+			want:     "Run `go te`",
+		},
+		{
+			name:     "emphasis markers inside code span",
+			markdown: "Run `a * b",
+			want:     "Run `a * b`",
+		},
+		{
+			name:     "snake case",
+			markdown: "Set snake_case",
+			want:     "Set snake_case",
+		},
+		{
+			name:     "earlier paragraph left alone",
+			markdown: "2 * 3\n\nSome **bol",
+			want:     "2 * 3\n\nSome **bol**",
+		},
+		{
+			name:     "list item",
+			markdown: "- one *a\n- two **b",
+			want:     "- one *a\n- two **b**",
+		},
+		{
+			name:     "unclosed fence",
+			markdown: "```go\nfunc main() {",
+			want:     "```go\nfunc main() {\n```\n",
+		},
+		{
+			name:     "emphasis inside unclosed fence",
+			markdown: "```\n**not bold",
+			want:     "```\n**not bold\n```\n",
+		},
+		{
+			name:     "closed fence",
+			markdown: "```\ncode\n```\n\nText **bo",
+			want:     "```\ncode\n```\n\nText **bo**",
+		},
+		{
+			name:     "pending list marker",
+			markdown: "Title\n-",
+			want:     "Title\n",
+		},
+		{
+			name:     "pending ordered list marker",
+			markdown: "Steps:\n\n1.",
+			want:     "Steps:\n\n",
+		},
+		{
+			name:     "pending fence",
+			markdown: "Code:\n``",
+			want:     "Code:\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repairMarkdown(tt.markdown); got != tt.want {
+				t.Errorf("repaired markdown mismatch, got=%q, want=%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	markdown := "# Report\n\nThe **build** passed.\n\n```go\nfunc main() {}\n```\n\n- one\n- two\n"
+
+	s := NewStream()
+	var update *StreamUpdate
+	for _, r := range markdown {
+		var err error
+		update, err = s.Append(string(r))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, block := range update.Blocks {
+			if section, ok := block.(*slack.SectionBlock); ok && strings.Contains(section.Text.Text, "**") {
+				t.Errorf("section shows literal markers, text=%q", section.Text.Text)
+			}
+		}
+	}
+
+	final, err := s.Close()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, err := ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(final.Blocks, want) {
+		t.Errorf("final blocks differ from a full conversion")
+	}
+	if !reflect.DeepEqual(update.Blocks, want) {
+		t.Errorf("last update differs from a full conversion")
+	}
+	if s.Text() != markdown {
+		t.Errorf("text mismatch, got=%q", s.Text())
+	}
+}
+
+func TestStreamUpdates(t *testing.T) {
+	s := NewStream()
+
+	steps := []struct {
+		chunk       string
+		wantTypes   []slack.MessageBlockType
+		wantChanged []int
+		wantRemoved int
+	}{
+		{
+			chunk:       "# Title\n\nSome **bo",
+			wantTypes:   []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection},
+			wantChanged: []int{0, 1},
+		},
+		{
+			chunk:       "ld** text",
+			wantTypes:   []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection},
+			wantChanged: []int{1},
+		},
+		{
+			chunk:       "\n\n```\ncode",
+			wantTypes:   []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection, slack.MBTRichText},
+			wantChanged: []int{2},
+		},
+		{
+			chunk:       "\n",
+			wantTypes:   []slack.MessageBlockType{slack.MBTHeader, slack.MBTSection, slack.MBTRichText},
+			wantChanged: nil,
+		},
+	}
+
+	for i, step := range steps {
+		update, err := s.Append(step.chunk)
+		if err != nil {
+			t.Fatalf("unexpected error at step=%d: %v", i, err)
+		}
+		if len(update.Blocks) != len(step.wantTypes) {
+			t.Fatalf("block count mismatch at step=%d, got=%v, want=%v", i, len(update.Blocks), len(step.wantTypes))
+		}
+		for j, block := range update.Blocks {
+			if block.BlockType() != step.wantTypes[j] {
+				t.Errorf("block type mismatch at step=%d, index=%d, got=%v, want=%v", i, j, block.BlockType(), step.wantTypes[j])
+			}
+		}
+		if !reflect.DeepEqual(update.Changed, step.wantChanged) {
+			t.Errorf("changed mismatch at step=%d, got=%v, want=%v", i, update.Changed, step.wantChanged)
+		}
+		if update.Removed != step.wantRemoved {
+			t.Errorf("removed mismatch at step=%d, got=%v, want=%v", i, update.Removed, step.wantRemoved)
+		}
+	}
+}
+
+func TestStreamFreezesCompleteBlocks(t *testing.T) {
+	s := NewStream()
+	if _, err := s.Append("First paragraph.\n\n- one\n\n- two\n\nLast\n"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(s.frozen); got != 2 {
+		t.Errorf("frozen block count mismatch, got=%v, want=%v", got, 2)
+	}
+	if got := string(s.text[s.frozenEnd:]); got != "Last\n" {
+		t.Errorf("tail mismatch, got=%q, want=%q", got, "Last\n")
+	}
+}
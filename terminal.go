@@ -0,0 +1,813 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/slack-go/slack"
+)
+
+// TerminalOption configures RenderBlocksToTerminal.
+type TerminalOption func(*terminalConfig)
+
+type terminalConfig struct {
+	width int
+	color bool
+}
+
+// TerminalWidth sets the width text is wrapped at. Zero or less disables wrapping.
+// The default is 80 columns.
+func TerminalWidth(width int) TerminalOption {
+	return func(c *terminalConfig) {
+		c.width = width
+	}
+}
+
+// TerminalColor sets whether ANSI styles, OSC 8 hyperlinks and box drawing are used.
+// Without them the output is plain text, which can serve as a notification fallback.
+// The default is true.
+func TerminalColor(enabled bool) TerminalOption {
+	return func(c *terminalConfig) {
+		c.color = enabled
+	}
+}
+
+const (
+	ansiRed     = 31
+	ansiGreen   = 32
+	ansiYellow  = 33
+	ansiBlue    = 34
+	ansiDefault = 0
+)
+
+// terminalStyle is the style of a span of terminal text.
+type terminalStyle struct {
+	bold, italic, strike, underline, dim bool
+	color                                int
+	url                                  string
+}
+
+type terminalSpan struct {
+	text  string
+	style terminalStyle
+}
+
+var (
+	terminalBullets      = []string{"•", "◦", "▪"}
+	terminalNumberFormat = []func(int) string{strconv.Itoa, alphaNumber, romanNumber}
+)
+
+// RenderBlocksToTerminal renders blocks as text for a terminal, wrapped to its width.
+// Rich text lists are prefixed with the bullets and numbers of their indent level,
+// and links are OSC 8 hyperlinks, which terminals without support show as their text.
+func RenderBlocksToTerminal(blocks []slack.Block, opts ...TerminalOption) string {
+	cfg := terminalConfig{width: 80, color: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	w := &terminalWriter{cfg: cfg}
+
+	var parts []string
+	for _, block := range blocks {
+		if block == nil {
+			continue
+		}
+		if lines := w.block(block, cfg.width); len(lines) > 0 {
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+type terminalWriter struct {
+	cfg terminalConfig
+}
+
+func (w *terminalWriter) block(block slack.Block, width int) []string {
+	switch b := block.(type) {
+	case *slack.HeaderBlock:
+		return w.header(b, width)
+	case slack.HeaderBlock:
+		return w.header(&b, width)
+	case *slack.SectionBlock:
+		return w.section(b, width)
+	case slack.SectionBlock:
+		return w.section(&b, width)
+	case *slack.RichTextBlock:
+		return w.richText(b, width)
+	case slack.RichTextBlock:
+		return w.richText(&b, width)
+	case *slack.DividerBlock, slack.DividerBlock:
+		return []string{w.divider(width)}
+	case *slack.ImageBlock:
+		return w.image(b, width)
+	case slack.ImageBlock:
+		return w.image(&b, width)
+	case *slack.ContextBlock:
+		return w.context(b, width)
+	case slack.ContextBlock:
+		return w.context(&b, width)
+	case *slack.ActionBlock:
+		return w.actions(b, width)
+	case slack.ActionBlock:
+		return w.actions(&b, width)
+	case *slack.TableBlock:
+		return w.table(b)
+	case slack.TableBlock:
+		return w.table(&b)
+	}
+	return nil
+}
+
+func (w *terminalWriter) header(b *slack.HeaderBlock, width int) []string {
+	if b.Text == nil || b.Text.Text == "" {
+		return nil
+	}
+	return w.wrap([]terminalSpan{{text: b.Text.Text, style: terminalStyle{bold: true}}}, width)
+}
+
+func (w *terminalWriter) section(b *slack.SectionBlock, width int) []string {
+	var lines []string
+	if b.Text != nil {
+		lines = append(lines, w.text(b.Text, terminalStyle{}, width)...)
+	}
+	for _, field := range b.Fields {
+		if field != nil {
+			lines = append(lines, w.text(field, terminalStyle{}, width)...)
+		}
+	}
+	if b.Accessory != nil {
+		if e := b.Accessory.ImageElement; e != nil && e.ImageURL != nil {
+			lines = append(lines, w.wrap(w.imageSpans(e.AltText, *e.ImageURL), width)...)
+		}
+		if e := b.Accessory.ButtonElement; e != nil {
+			lines = append(lines, w.wrap(w.buttonSpans(e), width)...)
+		}
+	}
+	return lines
+}
+
+// text renders a text object, whose text is mrkdwn or plain text.
+func (w *terminalWriter) text(t *slack.TextBlockObject, style terminalStyle, width int) []string {
+	if t.Type != slack.MarkdownType {
+		return w.wrap([]terminalSpan{{text: t.Text, style: style}}, width)
+	}
+	var lines []string
+	for _, n := range ParseMrkdwn(t.Text).Children {
+		lines = append(lines, w.mrkdwnBlock(n, style, width)...)
+	}
+	return lines
+}
+
+func (w *terminalWriter) mrkdwnBlock(n *MrkdwnNode, style terminalStyle, width int) []string {
+	switch n.Kind {
+	case MrkdwnParagraph:
+		return w.wrap(w.mrkdwnSpans(n.Children, style), width)
+	case MrkdwnQuote:
+		var lines []string
+		for _, child := range n.Children {
+			lines = append(lines, w.mrkdwnBlock(child, style, width-2)...)
+		}
+		return w.quote(lines)
+	case MrkdwnCodeBlock:
+		return w.preformatted(n.Text, width)
+	}
+	return nil
+}
+
+func (w *terminalWriter) mrkdwnSpans(nodes []*MrkdwnNode, style terminalStyle) []terminalSpan {
+	var spans []terminalSpan
+	for _, n := range nodes {
+		switch n.Kind {
+		case MrkdwnText:
+			spans = append(spans, terminalSpan{text: n.Text, style: style})
+		case MrkdwnLineBreak:
+			spans = append(spans, terminalSpan{text: "\n", style: style})
+		case MrkdwnBold:
+			s := style
+			s.bold = true
+			spans = append(spans, w.mrkdwnSpans(n.Children, s)...)
+		case MrkdwnItalic:
+			s := style
+			s.italic = true
+			spans = append(spans, w.mrkdwnSpans(n.Children, s)...)
+		case MrkdwnStrike:
+			s := style
+			s.strike = true
+			spans = append(spans, w.mrkdwnSpans(n.Children, s)...)
+		case MrkdwnCode:
+			spans = append(spans, w.codeSpan(n.Text, style))
+		case MrkdwnLink:
+			spans = append(spans, w.linkSpans(n.Text, n.URL, style)...)
+		case MrkdwnUserMention, MrkdwnUserGroupMention:
+			spans = append(spans, w.mention("@", n.ID, n.Text, style))
+		case MrkdwnChannelMention:
+			spans = append(spans, w.mention("#", n.ID, n.Text, style))
+		case MrkdwnBroadcast:
+			spans = append(spans, w.broadcast(n.ID, style))
+		case MrkdwnDate:
+			spans = append(spans, terminalSpan{text: n.Text, style: style})
+		case MrkdwnEmoji:
+			spans = append(spans, terminalSpan{text: emojiText(n.ID, ""), style: style})
+		}
+	}
+	return spans
+}
+
+func (w *terminalWriter) richText(b *slack.RichTextBlock, width int) []string {
+	var lines []string
+	for _, elem := range b.Elements {
+		switch e := elem.(type) {
+		case *slack.RichTextSection:
+			lines = append(lines, w.wrap(w.richTextSpans(trimTrailingNewline(e.Elements), terminalStyle{}), width)...)
+		case *slack.RichTextQuote:
+			lines = append(lines, w.quote(w.wrap(w.richTextSpans(trimTrailingNewline(e.Elements), terminalStyle{}), width-2))...)
+		case *slack.RichTextPreformatted:
+			lines = append(lines, w.preformatted(preformattedText(e.Elements), width)...)
+		case *slack.RichTextList:
+			lines = append(lines, w.list(e, width)...)
+		}
+	}
+	return lines
+}
+
+// list renders a rich text list with the bullets or numbers of its indent level.
+// Continuation lines of an item are aligned with its text.
+func (w *terminalWriter) list(list *slack.RichTextList, width int) []string {
+	indent := max(list.Indent, 0)
+	pad := strings.Repeat("   ", indent)
+
+	var lines []string
+	for i, item := range list.Elements {
+		marker := terminalBullets[indent%len(terminalBullets)] + " "
+		if list.Style == slack.RTEListOrdered {
+			marker = terminalNumberFormat[indent%len(terminalNumberFormat)](list.Offset+i+1) + ". "
+		}
+		var spans []terminalSpan
+		if section, ok := item.(*slack.RichTextSection); ok {
+			spans = w.richTextSpans(section.Elements, terminalStyle{})
+		}
+
+		prefixWidth := displayWidth(pad + marker)
+		for j, line := range w.wrap(spans, width-prefixWidth) {
+			if j == 0 {
+				lines = append(lines, pad+marker+line)
+			} else {
+				lines = append(lines, strings.Repeat(" ", prefixWidth)+line)
+			}
+		}
+	}
+	return lines
+}
+
+func (w *terminalWriter) richTextSpans(elements []slack.RichTextSectionElement, base terminalStyle) []terminalSpan {
+	var spans []terminalSpan
+	for _, elem := range elements {
+		switch e := elem.(type) {
+		case *slack.RichTextSectionTextElement:
+			style := richTextTerminalStyle(base, e.Style)
+			if e.Style != nil && e.Style.Code {
+				spans = append(spans, w.codeSpan(e.Text, style))
+			} else {
+				spans = append(spans, terminalSpan{text: e.Text, style: style})
+			}
+		case *slack.RichTextSectionLinkElement:
+			spans = append(spans, w.linkSpans(e.Text, e.URL, richTextTerminalStyle(base, e.Style))...)
+		case *slack.RichTextSectionUserElement:
+			spans = append(spans, w.mention("@", e.UserID, "", base))
+		case *slack.RichTextSectionChannelElement:
+			spans = append(spans, w.mention("#", e.ChannelID, "", base))
+		case *slack.RichTextSectionUserGroupElement:
+			spans = append(spans, w.mention("@", e.UsergroupID, "", base))
+		case *slack.RichTextSectionBroadcastElement:
+			spans = append(spans, w.broadcast(e.Range, base))
+		case *slack.RichTextSectionEmojiElement:
+			spans = append(spans, terminalSpan{text: emojiText(e.Name, e.Unicode), style: base})
+		case *slack.RichTextSectionTeamElement:
+			spans = append(spans, terminalSpan{text: e.TeamID, style: base})
+		case *slack.RichTextSectionDateElement:
+			spans = append(spans, terminalSpan{text: dateFallback(e), style: base})
+		case *slack.RichTextSectionColorElement:
+			spans = append(spans, terminalSpan{text: e.Value, style: base})
+		}
+	}
+	return spans
+}
+
+func richTextTerminalStyle(base terminalStyle, style *slack.RichTextSectionTextStyle) terminalStyle {
+	if style != nil {
+		base.bold = base.bold || style.Bold
+		base.italic = base.italic || style.Italic
+		base.strike = base.strike || style.Strike
+	}
+	return base
+}
+
+func (w *terminalWriter) codeSpan(text string, style terminalStyle) terminalSpan {
+	style.color = ansiRed
+	return terminalSpan{text: text, style: style}
+}
+
+// linkSpans renders a link as a hyperlink, or without color as its label followed by the URL.
+func (w *terminalWriter) linkSpans(label, url string, style terminalStyle) []terminalSpan {
+	if label == "" {
+		label = url
+	}
+	if !w.cfg.color {
+		if label == url {
+			return []terminalSpan{{text: label, style: style}}
+		}
+		return []terminalSpan{{text: label, style: style}, {text: " (" + url + ")", style: style}}
+	}
+	style.color, style.underline = ansiBlue, true
+	if isAllowedHTMLLink(url) {
+		style.url = url
+	}
+	return []terminalSpan{{text: label, style: style}}
+}
+
+func (w *terminalWriter) mention(prefix, id, label string, style terminalStyle) terminalSpan {
+	if label == "" {
+		label = id
+	}
+	style.color, style.bold = ansiBlue, true
+	return terminalSpan{text: prefix + label, style: style}
+}
+
+func (w *terminalWriter) broadcast(rng string, style terminalStyle) terminalSpan {
+	style.color, style.bold = ansiYellow, true
+	return terminalSpan{text: "@" + rng, style: style}
+}
+
+// quote prefixes lines with a bar, or without color with "> ".
+func (w *terminalWriter) quote(lines []string) []string {
+	prefix := "> "
+	if w.cfg.color {
+		prefix = w.render([]terminalSpan{{text: "│", style: terminalStyle{dim: true}}}) + " "
+	}
+	quoted := make([]string, len(lines))
+	for i, line := range lines {
+		quoted[i] = prefix + line
+	}
+	return quoted
+}
+
+// preformatted renders code in a box, or without color indented by four spaces.
+// Lines longer than the width are broken.
+func (w *terminalWriter) preformatted(code string, width int) []string {
+	code = stripTerminalControls(strings.TrimSuffix(code, "\n"))
+	if !w.cfg.color {
+		var lines []string
+		for _, line := range strings.Split(code, "\n") {
+			lines = append(lines, "    "+line)
+		}
+		return lines
+	}
+
+	inner := width - 4
+	var lines []string
+	for _, line := range strings.Split(code, "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		if width <= 0 || inner <= 0 {
+			lines = append(lines, line)
+			continue
+		}
+		for displayWidth(line) > inner {
+			head, tail := splitAtWidth(line, inner)
+			lines = append(lines, head)
+			line = tail
+		}
+		lines = append(lines, line)
+	}
+	if width <= 0 || inner <= 0 {
+		inner = 0
+		for _, line := range lines {
+			inner = max(inner, displayWidth(line))
+		}
+	}
+
+	border := terminalStyle{dim: true}
+	boxed := []string{w.render([]terminalSpan{{text: "┌" + strings.Repeat("─", inner+2) + "┐", style: border}})}
+	for _, line := range lines {
+		boxed = append(boxed, w.render([]terminalSpan{
+			{text: "│ ", style: border},
+			{text: line + strings.Repeat(" ", inner-displayWidth(line))},
+			{text: " │", style: border},
+		}))
+	}
+	boxed = append(boxed, w.render([]terminalSpan{{text: "└" + strings.Repeat("─", inner+2) + "┘", style: border}}))
+	return boxed
+}
+
+func (w *terminalWriter) divider(width int) string {
+	if !w.cfg.color {
+		return "---"
+	}
+	if width <= 0 {
+		width = 40
+	}
+	return w.render([]terminalSpan{{text: strings.Repeat("─", width), style: terminalStyle{dim: true}}})
+}
+
+func (w *terminalWriter) image(b *slack.ImageBlock, width int) []string {
+	var lines []string
+	if b.Title != nil && b.Title.Text != "" {
+		lines = append(lines, w.wrap([]terminalSpan{{text: b.Title.Text, style: terminalStyle{bold: true}}}, width)...)
+	}
+	return append(lines, w.wrap(w.imageSpans(b.AltText, b.ImageURL), width)...)
+}
+
+func (w *terminalWriter) imageSpans(alt, url string) []terminalSpan {
+	label := "[image: " + alt + "]"
+	if alt == "" {
+		label = "[image]"
+	}
+	return w.linkSpans(label, url, terminalStyle{})
+}
+
+func (w *terminalWriter) context(b *slack.ContextBlock, width int) []string {
+	style := terminalStyle{dim: true}
+	var spans []terminalSpan
+	for _, elem := range b.ContextElements.Elements {
+		var elemSpans []terminalSpan
+		switch e := elem.(type) {
+		case *slack.TextBlockObject:
+			if e.Type != slack.MarkdownType {
+				elemSpans = []terminalSpan{{text: e.Text, style: style}}
+				break
+			}
+			for _, n := range ParseMrkdwn(e.Text).Children {
+				elemSpans = append(elemSpans, w.mrkdwnSpans(n.Children, style)...)
+			}
+		case *slack.ImageBlockElement:
+			if e.AltText != "" {
+				elemSpans = []terminalSpan{{text: "[" + e.AltText + "]", style: style}}
+			}
+		}
+		if len(elemSpans) > 0 {
+			if len(spans) > 0 {
+				spans = append(spans, terminalSpan{text: "  ", style: style})
+			}
+			spans = append(spans, elemSpans...)
+		}
+	}
+	return w.wrap(spans, width)
+}
+
+func (w *terminalWriter) actions(b *slack.ActionBlock, width int) []string {
+	if b.Elements == nil {
+		return nil
+	}
+	var spans []terminalSpan
+	for _, elem := range b.Elements.ElementSet {
+		var elemSpans []terminalSpan
+		if button, ok := elem.(*slack.ButtonBlockElement); ok {
+			elemSpans = w.buttonSpans(button)
+		} else if w.cfg.color {
+			elemSpans = []terminalSpan{{text: "[" + string(elem.ElementType()) + "]", style: terminalStyle{dim: true}}}
+		}
+		if len(elemSpans) > 0 {
+			if len(spans) > 0 {
+				spans = append(spans, terminalSpan{text: "  "})
+			}
+			spans = append(spans, elemSpans...)
+		}
+	}
+	return w.wrap(spans, width)
+}
+
+// buttonSpans renders a button as its bracketed text, in green or red for the
+// primary and danger styles, linked to its URL if it has one.
+func (w *terminalWriter) buttonSpans(e *slack.ButtonBlockElement) []terminalSpan {
+	if e.Text == nil {
+		return nil
+	}
+	style := terminalStyle{bold: true}
+	switch e.Style {
+	case slack.StylePrimary:
+		style.color = ansiGreen
+	case slack.StyleDanger:
+		style.color = ansiRed
+	}
+	label := "[ " + e.Text.Text + " ]"
+	if e.URL == "" {
+		return []terminalSpan{{text: label, style: style}}
+	}
+	if !w.cfg.color {
+		return []terminalSpan{{text: label + " (" + e.URL + ")"}}
+	}
+	if isAllowedHTMLLink(e.URL) {
+		style.url = e.URL
+	}
+	return []terminalSpan{{text: label, style: style}}
+}
+
+// table renders a table with columns padded to their widest cell. Tables are not wrapped.
+func (w *terminalWriter) table(b *slack.TableBlock) []string {
+	columns := 0
+	for _, row := range b.Rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return nil
+	}
+
+	cells := make([][][]terminalSpan, len(b.Rows))
+	widths := make([]int, columns)
+	for i, row := range b.Rows {
+		cells[i] = make([][]terminalSpan, columns)
+		for j := 0; j < len(row); j++ {
+			if row[j] == nil {
+				continue
+			}
+			for _, elem := range row[j].Elements {
+				if section, ok := elem.(*slack.RichTextSection); ok {
+					for _, span := range w.richTextSpans(section.Elements, terminalStyle{}) {
+						span.text = strings.TrimSpace(strings.ReplaceAll(span.text, "\n", " "))
+						if span.text != "" {
+							cells[i][j] = append(cells[i][j], span)
+						}
+					}
+				}
+			}
+			widths[j] = max(widths[j], spansWidth(cells[i][j]))
+		}
+	}
+
+	separator, rule, cross := " | ", "-", "-+-"
+	if w.cfg.color {
+		separator, rule, cross = " │ ", "─", "─┼─"
+	}
+	border := terminalStyle{dim: true}
+
+	var lines []string
+	for i, row := range cells {
+		var spans []terminalSpan
+		for j, cell := range row {
+			if j > 0 {
+				spans = append(spans, terminalSpan{text: separator, style: border})
+			}
+			padding := widths[j] - spansWidth(cell)
+			align := slack.ColumnAlignmentLeft
+			if j < len(b.ColumnSettings) {
+				align = b.ColumnSettings[j].Align
+			}
+			left := 0
+			switch align {
+			case slack.ColumnAlignmentRight:
+				left = padding
+			case slack.ColumnAlignmentCenter:
+				left = padding / 2
+			}
+			spans = append(spans, terminalSpan{text: strings.Repeat(" ", left)})
+			spans = append(spans, cell...)
+			if j < columns-1 {
+				spans = append(spans, terminalSpan{text: strings.Repeat(" ", padding-left)})
+			}
+		}
+		lines = append(lines, strings.TrimRight(w.render(spans), " "))
+
+		if i == 0 && len(cells) > 1 {
+			rules := make([]string, columns)
+			for j, width := range widths {
+				rules[j] = strings.Repeat(rule, width)
+			}
+			lines = append(lines, w.render([]terminalSpan{{text: strings.Join(rules, cross), style: border}}))
+		}
+	}
+	return lines
+}
+
+// wrap lays out spans in lines of at most width columns, breaking at spaces, and
+// renders the lines. Words longer than a line are broken.
+func (w *terminalWriter) wrap(spans []terminalSpan, width int) []string {
+	var lines [][]terminalSpan
+	var line []terminalSpan
+	lineWidth := 0
+	flush := func() {
+		for len(line) > 0 && strings.TrimSpace(line[len(line)-1].text) == "" {
+			line = line[:len(line)-1]
+		}
+		lines = append(lines, line)
+		line, lineWidth = nil, 0
+	}
+
+	for _, span := range spans {
+		for _, token := range splitTerminalTokens(span.text) {
+			switch {
+			case token == "\n":
+				flush()
+			case strings.TrimSpace(token) == "":
+				if lineWidth > 0 {
+					line = append(line, terminalSpan{text: token, style: span.style})
+					lineWidth += displayWidth(token)
+				}
+			default:
+				tokenWidth := displayWidth(token)
+				if width > 0 && lineWidth > 0 && lineWidth+tokenWidth > width {
+					flush()
+				}
+				for width > 0 && tokenWidth > width {
+					head, tail := splitAtWidth(token, width)
+					line = append(line, terminalSpan{text: head, style: span.style})
+					flush()
+					token, tokenWidth = tail, displayWidth(tail)
+				}
+				line = append(line, terminalSpan{text: token, style: span.style})
+				lineWidth += tokenWidth
+			}
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		flush()
+	}
+
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = w.render(line)
+	}
+	return rendered
+}
+
+// render writes spans with the ANSI escape sequences of their styles, or as plain text.
+func (w *terminalWriter) render(spans []terminalSpan) string {
+	var sb strings.Builder
+	current := terminalStyle{}
+	for _, span := range spans {
+		if w.cfg.color && span.style != current {
+			sb.WriteString(terminalTransition(current, span.style))
+			current = span.style
+		}
+		sb.WriteString(stripTerminalControls(span.text))
+	}
+	if w.cfg.color && current != (terminalStyle{}) {
+		sb.WriteString(terminalTransition(current, terminalStyle{}))
+	}
+	return sb.String()
+}
+
+// terminalTransition returns the escape sequences that change the style from one to another.
+func terminalTransition(from, to terminalStyle) string {
+	var sb strings.Builder
+	if from.url != to.url {
+		if from.url != "" {
+			sb.WriteString("\x1b]8;;\x1b\\")
+		}
+		if to.url != "" {
+			sb.WriteString("\x1b]8;;" + stripTerminalControls(to.url) + "\x1b\\")
+		}
+	}
+
+	fromSGR, toSGR := from, to
+	fromSGR.url, toSGR.url = "", ""
+	if fromSGR == toSGR {
+		return sb.String()
+	}
+	var codes []string
+	if fromSGR != (terminalStyle{}) {
+		codes = append(codes, "0")
+	}
+	for _, attr := range []struct {
+		set  bool
+		code string
+	}{{to.bold, "1"}, {to.dim, "2"}, {to.italic, "3"}, {to.underline, "4"}, {to.strike, "9"}} {
+		if attr.set {
+			codes = append(codes, attr.code)
+		}
+	}
+	if to.color != ansiDefault {
+		codes = append(codes, strconv.Itoa(to.color))
+	}
+	if len(codes) > 0 {
+		sb.WriteString("\x1b[" + strings.Join(codes, ";") + "m")
+	}
+	return sb.String()
+}
+
+// stripTerminalControls removes the C0 and C1 control characters of s other than
+// line breaks and tabs, so that text cannot inject escape sequences.
+func stripTerminalControls(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isTerminalControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isTerminalControl reports whether r is a control character that stripTerminalControls removes.
+func isTerminalControl(r rune) bool {
+	return (r < 0x20 && r != '\n' && r != '\t') || (r >= 0x7f && r < 0xa0)
+}
+
+// splitTerminalTokens splits text into words, runs of spaces and newlines.
+func splitTerminalTokens(text string) []string {
+	var tokens []string
+	start := 0
+	for i, r := range text {
+		if r == '\n' {
+			if start < i {
+				tokens = append(tokens, text[start:i])
+			}
+			tokens = append(tokens, "\n")
+			start = i + 1
+			continue
+		}
+		if i > start {
+			prev := text[i-1]
+			if (prev == ' ') != (r == ' ') {
+				tokens = append(tokens, text[start:i])
+				start = i
+			}
+		}
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// splitAtWidth splits s after at most width columns, and after at least one rune.
+func splitAtWidth(s string, width int) (string, string) {
+	used := 0
+	for i, r := range s {
+		rw := runeDisplayWidth(r)
+		if used+rw > width && i > 0 {
+			return s[:i], s[i:]
+		}
+		used += rw
+	}
+	return s, ""
+}
+
+func spansWidth(spans []terminalSpan) int {
+	width := 0
+	for _, span := range spans {
+		width += displayWidth(span.text)
+	}
+	return width
+}
+
+// displayWidth returns the number of terminal columns s takes up.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeDisplayWidth(r)
+	}
+	return width
+}
+
+// runeDisplayWidth returns the columns of a rune: none for combining marks, joiners
+// and stripped control characters, and two for East Asian wide characters and emoji.
+func runeDisplayWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || r == 0x200d || (r >= 0xfe00 && r <= 0xfe0f) || isTerminalControl(r):
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf, r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6, r >= 0x1f300 && r <= 0x1faff, r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// emojiText returns an emoji's characters from its code points in hex, such as
+// "1f44d-1f3fb", or its shortcode when they are missing or invalid.
+func emojiText(name, unicode string) string {
+	if text, ok := emojiFromUnicode(unicode); ok {
+		return text
+	}
+	return ":" + name + ":"
+}
+
+// alphaNumber formats n as a lower-case letter sequence: a, b, ... z, aa, ab.
+func alphaNumber(n int) string {
+	if n <= 0 {
+		return strconv.Itoa(n)
+	}
+	var letters []byte
+	for n > 0 {
+		n--
+		letters = append([]byte{byte('a' + n%26)}, letters...)
+		n /= 26
+	}
+	return string(letters)
+}
+
+// romanNumber formats n as a lower-case roman numeral.
+func romanNumber(n int) string {
+	if n <= 0 || n >= 4000 {
+		return strconv.Itoa(n)
+	}
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	numerals := []string{"m", "cm", "d", "cd", "c", "xc", "l", "xl", "x", "ix", "v", "iv", "i"}
+	var sb strings.Builder
+	for i, value := range values {
+		for n >= value {
+			sb.WriteString(numerals[i])
+			n -= value
+		}
+	}
+	return sb.String()
+}
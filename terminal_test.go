@@ -0,0 +1,145 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestRenderBlocksToTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		width    int
+		want     string
+	}{
+		{
+			name:     "wrapping",
+			markdown: "# Title\n\nSome **bold** text that wraps at the width.",
+			width:    20,
+			want:     "Title\n\nSome bold text that\nwraps at the width.",
+		},
+		{
+			name:     "long word",
+			markdown: "abcdefghij",
+			width:    4,
+			want:     "abcd\nefgh\nij",
+		},
+		{
+			name:     "links",
+			markdown: "[docs](https://example.com) and <https://example.org>",
+			want:     "docs (https://example.com) and https://example.org",
+		},
+		{
+			name:     "lists",
+			markdown: "- one\n  - nested item that wraps\n    - deep\n\n1. one\n   1. first\n      1. roman",
+			width:    20,
+			want:     "• one\n   ◦ nested item\n     that wraps\n      ▪ deep\n\n1. one\n   a. first\n      i. roman",
+		},
+		{
+			name:     "quote and code",
+			markdown: "> quoted text\n\n```\nfunc main() {}\n```",
+			want:     "> quoted text\n\n    func main() {}",
+		},
+		{
+			name:     "table and divider",
+			markdown: "| Name | Count |\n| --- | ---: |\n| a | 1 |\n\n---",
+			want:     "Name | Count\n-----+------\na    |     1\n\n---",
+		},
+		{
+			name:     "wide characters",
+			markdown: "日本語の テキスト",
+			width:    8,
+			want:     "日本語の\nテキスト",
+		},
+	}
+
+	c := NewConverter(WithExtensions(extension.GFM))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, err := c.ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := RenderBlocksToTerminal(blocks, TerminalWidth(tt.width), TerminalColor(false))
+			if got != tt.want {
+				t.Errorf("RenderBlocksToTerminal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderBlocksToTerminalColor(t *testing.T) {
+	blocks := []slack.Block{
+		slack.NewRichTextBlock("",
+			slack.NewRichTextSection(
+				slack.NewRichTextSectionTextElement("bold", &slack.RichTextSectionTextStyle{Bold: true}),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionLinkElement("https://example.com", "docs", nil),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionLinkElement("javascript:alert(1)", "bad", nil),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				&slack.RichTextSectionEmojiElement{Type: slack.RTSEEmoji, Name: "tada", Unicode: "1f389"},
+			),
+			&slack.RichTextQuote{Type: slack.RTEQuote, Elements: []slack.RichTextSectionElement{
+				slack.NewRichTextSectionTextElement("quote", &slack.RichTextSectionTextStyle{Strike: true}),
+			}},
+			&slack.RichTextList{Type: slack.RTEList, Style: slack.RTEListOrdered, Indent: 1, Offset: 2, Elements: []slack.RichTextElement{
+				slack.NewRichTextSection(slack.NewRichTextSectionTextElement("third", nil)),
+			}},
+			&slack.RichTextPreformatted{RichTextSection: slack.RichTextSection{Type: slack.RTEPreformatted, Elements: []slack.RichTextSectionElement{
+				slack.NewRichTextSectionTextElement("code", nil),
+			}}},
+		),
+		slack.NewActionBlock("",
+			slack.NewButtonBlockElement("", "", slack.NewTextBlockObject(slack.PlainTextType, "Delete", false, false)).WithStyle(slack.StyleDanger),
+		),
+	}
+
+	got := RenderBlocksToTerminal(blocks, TerminalWidth(12))
+	for _, want := range []string{
+		"\x1b[1mbold\x1b[0m ",
+		"\x1b]8;;https://example.com\x1b\\\x1b[4;34mdocs\x1b]8;;\x1b\\\x1b[0m",
+		"\x1b[4;34mbad\x1b[0m 🎉",
+		"\n   c. third\n",
+		"\x1b[2m│\x1b[0m \x1b[9mquote\x1b[0m",
+		"\x1b[2m┌──────────┐\x1b[0m\n\x1b[2m│ \x1b[0mcode    \x1b[2m │\x1b[0m\n\x1b[2m└──────────┘\x1b[0m",
+		"\x1b[1;31m[ Delete ]\x1b[0m",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderBlocksToTerminal() does not contain %q:\n%q", want, got)
+		}
+	}
+	if strings.Contains(got, "javascript:") {
+		t.Errorf("RenderBlocksToTerminal() links an unsafe URL:\n%q", got)
+	}
+}
+
+func TestRenderBlocksToTerminalStripsControls(t *testing.T) {
+	blocks := []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "clear\x1b[2J screen\u009b2J and <https://example.com/\x1b]8;;https://evil.example\x07|li\x1bnk>", false, false), nil, nil),
+		slack.NewRichTextBlock("",
+			slack.NewRichTextSection(slack.NewRichTextSectionTextElement("bell\x07\ttab", nil)),
+			&slack.RichTextPreformatted{RichTextSection: slack.RichTextSection{Type: slack.RTEPreformatted, Elements: []slack.RichTextSectionElement{
+				slack.NewRichTextSectionTextElement("code\x1b]0;title\x07\n", nil),
+			}}},
+		),
+	}
+
+	for _, color := range []bool{true, false} {
+		got := RenderBlocksToTerminal(blocks, TerminalColor(color))
+		for _, injected := range []string{"\x1b[2J", "\u009b", "\x07", "\x1b]8;;https://evil", "\x1b]0;", "li\x1bnk"} {
+			if strings.Contains(got, injected) {
+				t.Errorf("RenderBlocksToTerminal(color=%v) contains %q:\n%q", color, injected, got)
+			}
+		}
+		if !color && strings.Contains(got, "\x1b") {
+			t.Errorf("RenderBlocksToTerminal(color=false) contains an escape sequence:\n%q", got)
+		}
+		if !strings.Contains(got, "bell\ttab") || !strings.Contains(got, "clear[2J screen2J") {
+			t.Errorf("RenderBlocksToTerminal(color=%v) lost the text:\n%q", color, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"context"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Message is a message of a document split into a thread.
+type Message struct {
+	// Text is the fallback text of the message.
+	Text string
+	// Blocks are the blocks of the message.
+	Blocks []slack.Block
+	// Reply is set for messages to post as replies in the thread of the first message.
+	Reply bool
+}
+
+// ConvertMarkdownTextToThread converts a markdown text to a parent message and
+// replies in its thread, split at the headings of the given level or above. The
+// parent holds everything before the first such heading after the start of the
+// document, such as the title and the introduction, and each reply holds one
+// section starting at its heading. With level 2, a report's H1 and introduction
+// are the parent and each H2 section is a reply.
+//
+// Sections with more blocks than fit in a message continue in further replies.
+// Each message gets the fallback text of its section, or the plain text of its
+// blocks if it continues one.
+func ConvertMarkdownTextToThread(markdown string, level int) ([]Message, error) {
+	return defaultConverter.ConvertMarkdownTextToThread(markdown, level)
+}
+
+// ConvertMarkdownTextToThread converts a markdown text to a parent message and
+// replies split at the headings of the given level or above, with the converter's
+// settings and at most its blocks per message.
+func (c *Converter) ConvertMarkdownTextToThread(markdown string, level int) ([]Message, error) {
+	source := []byte(markdown)
+	if c.maxInputSize > 0 && len(source) > c.maxInputSize {
+		return nil, &LimitError{Err: ErrInputTooLarge, Limit: c.maxInputSize}
+	}
+	doc := c.markdown.Parser().Parse(text.NewReader(source))
+
+	messages := []Message{}
+	total := 0
+	for _, section := range splitSections(doc, level) {
+		blocks, err := c.convertNode(context.Background(), section, source)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		total += len(blocks)
+		if c.maxOutputBlocks > 0 && total > c.maxOutputBlocks {
+			return nil, &LimitError{Err: ErrTooManyBlocks, Limit: c.maxOutputBlocks}
+		}
+
+		for i, chunk := range SplitBlocks(blocks, WithMaxBlocks(c.maxBlocks)) {
+			msg := Message{Blocks: chunk, Reply: len(messages) > 0}
+			if i == 0 {
+				msg.Text = c.ConvertNodeToFallbackText(section, source)
+			} else {
+				msg.Text = c.blocksFallbackText(chunk)
+			}
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// splitSections moves the top-level nodes of doc into documents that each start at
+// a heading of the given level or above, except the first, which holds the nodes
+// before the first such heading that follows other nodes.
+func splitSections(doc ast.Node, level int) []*ast.Document {
+	section := ast.NewDocument()
+	sections := []*ast.Document{section}
+	for n := doc.FirstChild(); n != nil; {
+		next := n.NextSibling()
+		if heading, ok := n.(*ast.Heading); ok && heading.Level <= level && section.HasChildren() {
+			section = ast.NewDocument()
+			sections = append(sections, section)
+		}
+		doc.RemoveChild(doc, n)
+		section.AppendChild(section, n)
+		n = next
+	}
+	return sections
+}
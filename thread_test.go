@@ -0,0 +1,123 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvertMarkdownTextToThread(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		level    int
+		// want holds the markdown of each message, prefixed with "> " for replies
+		want []string
+	}{
+		{
+			name:     "report",
+			markdown: "# Weekly report\n\nAll good.\n\n## Wins\n\n- shipped\n\n### Details\n\ntext\n\n## Risks\n\nnone",
+			level:    2,
+			want: []string{
+				"# Weekly report\n\nAll good.",
+				"> ## Wins\n\n- shipped\n\n### Details\n\ntext",
+				"> ## Risks\n\nnone",
+			},
+		},
+		{
+			name:     "split at H1",
+			markdown: "intro\n\n# One\n\n## Sub\n\n# Two",
+			level:    1,
+			want: []string{
+				"intro",
+				"> # One\n\n## Sub",
+				"> # Two",
+			},
+		},
+		{
+			name:     "starts with a section",
+			markdown: "## A\n\na\n\n## B\n\nb",
+			level:    2,
+			want: []string{
+				"## A\n\na",
+				"> ## B\n\nb",
+			},
+		},
+		{
+			name:     "no headings",
+			markdown: "just text",
+			level:    2,
+			want:     []string{"just text"},
+		},
+		{
+			name:     "empty",
+			markdown: "",
+			level:    2,
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertMarkdownTextToThread(tt.markdown, tt.level)
+			if err != nil {
+				t.Fatalf("ConvertMarkdownTextToThread() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d messages, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, msg := range got {
+				markdown, reply := strings.CutPrefix(tt.want[i], "> ")
+				if msg.Reply != reply {
+					t.Errorf("message %d Reply = %v, want %v", i, msg.Reply, reply)
+				}
+				want, err := ConvertMarkdownTextToBlocks(markdown)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotJSON, _ := json.Marshal(msg.Blocks)
+				wantJSON, _ := json.Marshal(want)
+				if string(gotJSON) != string(wantJSON) {
+					t.Errorf("message %d blocks = %s, want %s", i, gotJSON, wantJSON)
+				}
+				if text := ConvertMarkdownTextToFallbackText(markdown); msg.Text != text {
+					t.Errorf("message %d text = %q, want %q", i, msg.Text, text)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertMarkdownTextToThreadLongSection(t *testing.T) {
+	c := NewConverter(WithBlocksPerMessage(2))
+	got, err := c.ConvertMarkdownTextToThread("# Title\n\n## Long\n\none\n\ntwo\n\nthree", 2)
+	if err != nil {
+		t.Fatalf("ConvertMarkdownTextToThread() error = %v", err)
+	}
+
+	var summary []string
+	for _, msg := range got {
+		summary = append(summary, msg.Text)
+		if !msg.Reply && len(summary) > 1 {
+			t.Errorf("message %d is not a reply", len(summary)-1)
+		}
+	}
+	want := []string{"Title", "Long\none two three", "two three"}
+	if strings.Join(summary, "|") != strings.Join(want, "|") {
+		t.Errorf("texts = %q, want %q", summary, want)
+	}
+}
+
+func TestConvertMarkdownTextToThreadLimits(t *testing.T) {
+	var limitErr *LimitError
+	c := NewConverter(WithMaxOutputBlocks(3))
+	if _, err := c.ConvertMarkdownTextToThread("# A\n\na\n\n## B\n\nb\n\n## C\n\nc", 2); !errors.As(err, &limitErr) || limitErr.Err != ErrTooManyBlocks {
+		t.Errorf("ConvertMarkdownTextToThread() error = %v, want ErrTooManyBlocks", err)
+	}
+
+	c = NewConverter(WithMaxInputSize(4))
+	if _, err := c.ConvertMarkdownTextToThread("# Title", 2); !errors.As(err, &limitErr) || limitErr.Err != ErrInputTooLarge {
+		t.Errorf("ConvertMarkdownTextToThread() error = %v, want ErrInputTooLarge", err)
+	}
+}
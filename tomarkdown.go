@@ -0,0 +1,598 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+// MarkdownOption configures ConvertBlocksToMarkdown.
+type MarkdownOption func(*markdownConfig)
+
+type markdownConfig struct {
+	user      func(userID string) string
+	channel   func(channelID string) string
+	userGroup func(userGroupID string) string
+	broadcast func(rng string) string
+	emoji     func(name, unicode string) string
+}
+
+// MarkdownUserFormat sets how user mentions are written. The default is Slack's "<@U123>".
+func MarkdownUserFormat(f func(userID string) string) MarkdownOption {
+	return func(c *markdownConfig) {
+		c.user = f
+	}
+}
+
+// MarkdownChannelFormat sets how channel mentions are written. The default is Slack's "<#C123>".
+func MarkdownChannelFormat(f func(channelID string) string) MarkdownOption {
+	return func(c *markdownConfig) {
+		c.channel = f
+	}
+}
+
+// MarkdownUserGroupFormat sets how user group mentions are written. The default is
+// Slack's "<!subteam^S123>".
+func MarkdownUserGroupFormat(f func(userGroupID string) string) MarkdownOption {
+	return func(c *markdownConfig) {
+		c.userGroup = f
+	}
+}
+
+// MarkdownBroadcastFormat sets how broadcasts such as @here are written. The default is
+// Slack's "<!here>".
+func MarkdownBroadcastFormat(f func(rng string) string) MarkdownOption {
+	return func(c *markdownConfig) {
+		c.broadcast = f
+	}
+}
+
+// MarkdownEmojiFormat sets how emoji are written. unicode is the emoji's code points in
+// hex, such as "1f389", and is empty for custom emoji. The default is the ":name:" shortcode.
+func MarkdownEmojiFormat(f func(name, unicode string) string) MarkdownOption {
+	return func(c *markdownConfig) {
+		c.emoji = f
+	}
+}
+
+// ConvertBlocksToMarkdown converts slack blocks to markdown. It is the reverse of
+// ConvertMarkdownTextToBlocks: converting its output back gives the same blocks for
+// blocks created by this package. Struck text is written between single tildes, which
+// is mrkdwn strikethrough with or without GFM. Block types without a markdown
+// counterpart, such as inputs, are skipped.
+func ConvertBlocksToMarkdown(blocks []slack.Block, opts ...MarkdownOption) (string, error) {
+	w := newMarkdownWriter(opts)
+	var parts []string
+	for i, block := range blocks {
+		if block == nil {
+			return "", fmt.Errorf("blocks[%d]: must not be null", i)
+		}
+		if md := w.block(block); md != "" {
+			parts = append(parts, md)
+		}
+	}
+
+	return strings.Join(parts, "\n\n"), nil
+}
+
+type markdownWriter struct {
+	cfg markdownConfig
+}
+
+func newMarkdownWriter(opts []MarkdownOption) *markdownWriter {
+	cfg := markdownConfig{
+		user:      func(id string) string { return "<@" + id + ">" },
+		channel:   func(id string) string { return "<#" + id + ">" },
+		userGroup: func(id string) string { return "<!subteam^" + id + ">" },
+		broadcast: func(rng string) string { return "<!" + rng + ">" },
+		emoji:     func(name, _ string) string { return ":" + name + ":" },
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &markdownWriter{cfg: cfg}
+}
+
+func (w *markdownWriter) block(block slack.Block) string {
+	switch b := block.(type) {
+	case *slack.HeaderBlock:
+		return w.header(b)
+	case slack.HeaderBlock:
+		return w.header(&b)
+	case *slack.SectionBlock:
+		return w.section(b)
+	case slack.SectionBlock:
+		return w.section(&b)
+	case *slack.RichTextBlock:
+		return w.richText(b)
+	case slack.RichTextBlock:
+		return w.richText(&b)
+	case *slack.DividerBlock, slack.DividerBlock:
+		return "---"
+	case *slack.ImageBlock:
+		return w.image(b)
+	case slack.ImageBlock:
+		return w.image(&b)
+	case *slack.ContextBlock:
+		return w.context(b)
+	case slack.ContextBlock:
+		return w.context(&b)
+	case *slack.ActionBlock:
+		return w.actions(b)
+	case slack.ActionBlock:
+		return w.actions(&b)
+	case *slack.TableBlock:
+		return w.table(b)
+	case slack.TableBlock:
+		return w.table(&b)
+	}
+	return ""
+}
+
+func (w *markdownWriter) header(b *slack.HeaderBlock) string {
+	if b.Text == nil || b.Text.Text == "" {
+		return ""
+	}
+	return "# " + escapeMarkdown(strings.ReplaceAll(b.Text.Text, "\n", " "))
+}
+
+func (w *markdownWriter) section(b *slack.SectionBlock) string {
+	var parts []string
+	if b.Text != nil {
+		parts = append(parts, w.text(b.Text))
+	}
+	for _, field := range b.Fields {
+		if field != nil {
+			parts = append(parts, w.text(field))
+		}
+	}
+	if b.Accessory != nil {
+		if e := b.Accessory.ImageElement; e != nil && e.ImageURL != nil {
+			parts = append(parts, imageMarkdown(e.AltText, *e.ImageURL, ""))
+		}
+		if e := b.Accessory.ButtonElement; e != nil {
+			parts = append(parts, w.button(e))
+		}
+	}
+	return joinNonEmpty(parts, "\n\n")
+}
+
+// text converts a text object, whose text is mrkdwn or plain text, to markdown.
+func (w *markdownWriter) text(t *slack.TextBlockObject) string {
+	if t.Type == slack.MarkdownType {
+		return w.mrkdwnBlocks(ParseMrkdwn(t.Text).Children)
+	}
+	return escapeMarkdown(t.Text)
+}
+
+func (w *markdownWriter) image(b *slack.ImageBlock) string {
+	if b.ImageURL == "" {
+		return ""
+	}
+	title := ""
+	if b.Title != nil {
+		title = b.Title.Text
+	}
+	return imageMarkdown(b.AltText, b.ImageURL, title)
+}
+
+func (w *markdownWriter) context(b *slack.ContextBlock) string {
+	var parts []string
+	for _, elem := range b.ContextElements.Elements {
+		switch e := elem.(type) {
+		case *slack.TextBlockObject:
+			parts = append(parts, w.text(e))
+		case *slack.ImageBlockElement:
+			if e.ImageURL != nil {
+				parts = append(parts, imageMarkdown(e.AltText, *e.ImageURL, ""))
+			}
+		}
+	}
+	return joinNonEmpty(parts, " ")
+}
+
+func (w *markdownWriter) actions(b *slack.ActionBlock) string {
+	if b.Elements == nil {
+		return ""
+	}
+	var parts []string
+	for _, elem := range b.Elements.ElementSet {
+		if button, ok := elem.(*slack.ButtonBlockElement); ok {
+			parts = append(parts, w.button(button))
+		}
+	}
+	return joinNonEmpty(parts, " | ")
+}
+
+// button writes a button as a link to its URL, or as its text when it has none.
+func (w *markdownWriter) button(e *slack.ButtonBlockElement) string {
+	if e.Text == nil {
+		return ""
+	}
+	text := escapeMarkdown(e.Text.Text)
+	if e.URL == "" {
+		return text
+	}
+	return "[" + text + "](" + linkDestination(e.URL) + ")"
+}
+
+func (w *markdownWriter) table(b *slack.TableBlock) string {
+	if len(b.Rows) == 0 {
+		return ""
+	}
+	columns := len(b.ColumnSettings)
+	for _, row := range b.Rows {
+		columns = max(columns, len(row))
+	}
+
+	var lines []string
+	for i, row := range b.Rows {
+		cells := make([]string, columns)
+		for j := range cells {
+			cells[j] = " "
+			if j < len(row) && row[j] != nil {
+				// The header row is bold anyway, so its bold style is dropped
+				cell := strings.ReplaceAll(w.richTextCell(row[j], i == 0), "|", "\\|")
+				if cell != "" {
+					cells[j] = cell
+				}
+			}
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+
+		if i == 0 {
+			delimiters := make([]string, columns)
+			for j := range delimiters {
+				delimiters[j] = "---"
+				if j < len(b.ColumnSettings) {
+					switch b.ColumnSettings[j].Align {
+					case slack.ColumnAlignmentLeft:
+						delimiters[j] = ":--"
+					case slack.ColumnAlignmentCenter:
+						delimiters[j] = ":-:"
+					case slack.ColumnAlignmentRight:
+						delimiters[j] = "--:"
+					}
+				}
+			}
+			lines = append(lines, "| "+strings.Join(delimiters, " | ")+" |")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// richTextCell writes the sections of a table cell on a single line.
+func (w *markdownWriter) richTextCell(b *slack.RichTextBlock, plain bool) string {
+	var parts []string
+	for _, elem := range b.Elements {
+		if section, ok := elem.(*slack.RichTextSection); ok {
+			elements := section.Elements
+			if plain {
+				elements = withoutBold(elements)
+			}
+			parts = append(parts, w.inline(elements))
+		}
+	}
+	return strings.ReplaceAll(joinNonEmpty(parts, " "), "\n", " ")
+}
+
+func (w *markdownWriter) richText(b *slack.RichTextBlock) string {
+	var parts []string
+	var lists []*slack.RichTextList
+	flushLists := func() {
+		if len(lists) > 0 {
+			parts = append(parts, w.lists(lists))
+			lists = nil
+		}
+	}
+
+	for _, elem := range b.Elements {
+		if list, ok := elem.(*slack.RichTextList); ok {
+			lists = append(lists, list)
+			continue
+		}
+		flushLists()
+
+		switch e := elem.(type) {
+		// Sections typed in Slack end with the newline before the next element
+		case *slack.RichTextSection:
+			parts = append(parts, escapeLineStarts(strings.TrimRight(w.inline(e.Elements), "\n")))
+		case *slack.RichTextQuote:
+			parts = append(parts, prefixLines(escapeLineStarts(strings.TrimRight(w.inline(e.Elements), "\n")), "> "))
+		case *slack.RichTextPreformatted:
+			parts = append(parts, codeFence(preformattedText(e.Elements)))
+		}
+	}
+	flushLists()
+
+	return joinNonEmpty(parts, "\n\n")
+}
+
+// lists writes consecutive rich text lists as one markdown list, nesting the lists
+// by their indent under the last item of the enclosing level.
+func (w *markdownWriter) lists(lists []*slack.RichTextList) string {
+	var lines []string
+	var widths []int
+	for _, list := range lists {
+		indent := max(list.Indent, 0)
+		for len(widths) < indent {
+			widths = append(widths, 2)
+		}
+		prefix := 0
+		for _, width := range widths[:indent] {
+			prefix += width
+		}
+
+		for i, item := range list.Elements {
+			marker := "- "
+			if list.Style == slack.RTEListOrdered {
+				marker = strconv.Itoa(list.Offset+i+1) + ". "
+			}
+			widths = append(widths[:indent], len(marker))
+
+			var text string
+			if section, ok := item.(*slack.RichTextSection); ok {
+				text = taskMarkdown(escapeLineStarts(w.inline(section.Elements)))
+			}
+			lines = append(lines, strings.Repeat(" ", prefix)+marker+
+				strings.ReplaceAll(text, "\n", "\n"+strings.Repeat(" ", prefix+len(marker))))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline writes rich text section elements as inline markdown.
+func (w *markdownWriter) inline(elements []slack.RichTextSectionElement) string {
+	var sb strings.Builder
+	for _, elem := range mergeTextElements(elements) {
+		switch e := elem.(type) {
+		case *slack.RichTextSectionTextElement:
+			if e.Style != nil && e.Style.Code {
+				sb.WriteString(styleMarkdown(codeSpan(e.Text), e.Style))
+			} else {
+				sb.WriteString(styleMarkdown(escapeInlineMarkdown(e.Text), e.Style))
+			}
+		case *slack.RichTextSectionLinkElement:
+			text := e.Text
+			if text == "" {
+				sb.WriteString(styleMarkdown("<"+e.URL+">", e.Style))
+				continue
+			}
+			sb.WriteString(styleMarkdown("["+escapeInlineMarkdown(text)+"]("+linkDestination(e.URL)+")", e.Style))
+		case *slack.RichTextSectionUserElement:
+			sb.WriteString(styleMarkdown(w.cfg.user(e.UserID), e.Style))
+		case *slack.RichTextSectionChannelElement:
+			sb.WriteString(styleMarkdown(w.cfg.channel(e.ChannelID), e.Style))
+		case *slack.RichTextSectionUserGroupElement:
+			sb.WriteString(w.cfg.userGroup(e.UsergroupID))
+		case *slack.RichTextSectionBroadcastElement:
+			sb.WriteString(w.cfg.broadcast(e.Range))
+		case *slack.RichTextSectionEmojiElement:
+			sb.WriteString(styleMarkdown(w.cfg.emoji(e.Name, e.Unicode), e.Style))
+		case *slack.RichTextSectionTeamElement:
+			sb.WriteString(styleMarkdown(escapeInlineMarkdown(e.TeamID), e.Style))
+		case *slack.RichTextSectionDateElement:
+			sb.WriteString(escapeInlineMarkdown(dateFallback(e)))
+		case *slack.RichTextSectionColorElement:
+			sb.WriteString(escapeInlineMarkdown(e.Value))
+		}
+	}
+	return sb.String()
+}
+
+// lineStartPattern matches the start of a line that markdown would read as a block
+// marker, such as a heading, quote, list item or setext underline. The second group
+// is the character to escape.
+var lineStartPattern = regexp.MustCompile(`^\s*(?:(#)#{0,5}(?:\s|$)|(>)|([-+*])(?:\s|$)|\d{1,9}([.)])(?:\s|$)|([=-])[=-]*\s*$)`)
+
+// escapeMarkdown escapes text so that markdown renders it literally.
+func escapeMarkdown(text string) string {
+	return escapeLineStarts(escapeInlineMarkdown(text))
+}
+
+// escapeLineStarts escapes the block markers at the start of the lines of md.
+func escapeLineStarts(md string) string {
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		m := lineStartPattern.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+		for group := 1; group < len(m)/2; group++ {
+			if start := m[2*group]; start >= 0 {
+				lines[i] = line[:start] + "\\" + line[start:]
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeInlineMarkdown escapes the characters of text that start inline markdown.
+// Underscores inside words and characters that start nothing are kept as they are.
+func escapeInlineMarkdown(text string) string {
+	var sb strings.Builder
+	for i, r := range text {
+		switch r {
+		case '\\', '`', '*', '~', '[':
+			sb.WriteByte('\\')
+		case '_':
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			if !isWordRune(prev) || !isWordRune(next) {
+				sb.WriteByte('\\')
+			}
+		case '<', '&':
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			if unicode.IsLetter(next) || strings.ContainsRune("/!?#", next) {
+				sb.WriteByte('\\')
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// styleMarkdown wraps markdown in the markers of a rich text style. Code is
+// expected to be applied by the caller. Spaces at the ends are kept outside
+// the markers, where markdown requires them.
+func styleMarkdown(md string, style *slack.RichTextSectionTextStyle) string {
+	if style == nil || strings.TrimSpace(md) == "" {
+		return md
+	}
+	trimmed := strings.TrimLeft(md, " ")
+	leading := md[:len(md)-len(trimmed)]
+	inner := strings.TrimRight(trimmed, " ")
+	trailing := trimmed[len(inner):]
+
+	if style.Strike {
+		inner = "~" + inner + "~"
+	}
+	if style.Italic {
+		inner = "*" + inner + "*"
+	}
+	if style.Bold {
+		inner = "**" + inner + "**"
+	}
+	return leading + inner + trailing
+}
+
+// codeSpan writes text as a code span, delimited by more backticks than text contains.
+func codeSpan(text string) string {
+	ticks := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return ticks + " " + text + " " + ticks
+	}
+	return ticks + text + ticks
+}
+
+// codeFence writes text as a fenced code block.
+func codeFence(text string) string {
+	fence := strings.Repeat("`", max(3, longestRun(text, '`')+1))
+	return fence + "\n" + strings.TrimSuffix(text, "\n") + "\n" + fence
+}
+
+func longestRun(text string, ch byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(text); i++ {
+		if text[i] == ch {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// preformattedText returns the text of preformatted elements, with links as their URL.
+func preformattedText(elements []slack.RichTextSectionElement) string {
+	var sb strings.Builder
+	for _, elem := range elements {
+		switch e := elem.(type) {
+		case *slack.RichTextSectionTextElement:
+			sb.WriteString(e.Text)
+		case *slack.RichTextSectionLinkElement:
+			if e.Text != "" {
+				sb.WriteString(e.Text)
+			} else {
+				sb.WriteString(e.URL)
+			}
+		default:
+			sb.WriteString(richTextElementsToMrkdwn([]slack.RichTextSectionElement{elem}))
+		}
+	}
+	return sb.String()
+}
+
+// mergeTextElements joins adjacent text elements that have the same style, so
+// that their markers are written once.
+func mergeTextElements(elements []slack.RichTextSectionElement) []slack.RichTextSectionElement {
+	merged := make([]slack.RichTextSectionElement, 0, len(elements))
+	for _, elem := range elements {
+		text, ok := elem.(*slack.RichTextSectionTextElement)
+		if ok && len(merged) > 0 {
+			if last, ok := merged[len(merged)-1].(*slack.RichTextSectionTextElement); ok && sameStyle(last.Style, text.Style) {
+				merged[len(merged)-1] = &slack.RichTextSectionTextElement{Type: slack.RTSEText, Text: last.Text + text.Text, Style: last.Style}
+				continue
+			}
+		}
+		merged = append(merged, elem)
+	}
+	return merged
+}
+
+func sameStyle(a, b *slack.RichTextSectionTextStyle) bool {
+	if a == nil || b == nil {
+		return (a == nil || *a == slack.RichTextSectionTextStyle{}) && (b == nil || *b == slack.RichTextSectionTextStyle{})
+	}
+	return *a == *b
+}
+
+// withoutBold returns elements with the bold style of text elements removed.
+func withoutBold(elements []slack.RichTextSectionElement) []slack.RichTextSectionElement {
+	result := make([]slack.RichTextSectionElement, len(elements))
+	for i, elem := range elements {
+		result[i] = elem
+		if text, ok := elem.(*slack.RichTextSectionTextElement); ok && text.Style != nil && text.Style.Bold {
+			style := *text.Style
+			style.Bold = false
+			result[i] = &slack.RichTextSectionTextElement{Type: slack.RTSEText, Text: text.Text, Style: &style}
+		}
+	}
+	return result
+}
+
+// taskMarkdown turns the check boxes this package renders task list items with
+// back into GFM task list markers.
+func taskMarkdown(item string) string {
+	if rest, ok := strings.CutPrefix(item, "☑ "); ok {
+		return "[x] " + rest
+	}
+	if rest, ok := strings.CutPrefix(item, "☐ "); ok {
+		return "[ ] " + rest
+	}
+	return item
+}
+
+func dateFallback(e *slack.RichTextSectionDateElement) string {
+	if e.Fallback != nil {
+		return *e.Fallback
+	}
+	return e.Timestamp.Time().UTC().Format("2006-01-02 15:04 MST")
+}
+
+func imageMarkdown(alt, url, title string) string {
+	if title != "" {
+		return fmt.Sprintf("![%s](%s %q)", escapeMarkdown(alt), linkDestination(url), title)
+	}
+	return "![" + escapeMarkdown(alt) + "](" + linkDestination(url) + ")"
+}
+
+// linkDestination writes a URL as a link destination, enclosing it in angle
+// brackets when it contains spaces or parentheses.
+func linkDestination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+func prefixLines(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+func joinNonEmpty(parts []string, sep string) string {
+	nonEmpty := parts[:0:0]
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
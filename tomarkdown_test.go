@@ -0,0 +1,234 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/slack-go/slack"
+	"github.com/yuin/goldmark/extension"
+)
+
+func TestConvertBlocksToMarkdown(t *testing.T) {
+	imageURL := "https://example.com/a.png"
+	fallback := "Jan 2nd"
+
+	tests := []struct {
+		name   string
+		blocks []slack.Block
+		want   string
+	}{
+		{
+			name:   "header",
+			blocks: []slack.Block{slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, "Release #1", false, false))},
+			want:   "# Release #1",
+		},
+		{
+			name: "section mrkdwn",
+			blocks: []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType,
+				"*bold* _italic_ ~strike~ `code` <https://example.com|docs> <https://example.com> &lt;tag&gt; 2 * 3", false, false), nil, nil)},
+			want: "**bold** *italic* ~strike~ `code` [docs](https://example.com) <https://example.com> \\<tag> 2 \\* 3",
+		},
+		{
+			name: "section mentions",
+			blocks: []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType,
+				"<@U1> <#C1|general> <!subteam^S1|team> <!here> <!date^1700000000^{date}|Nov 14>", false, false), nil, nil)},
+			want: "<@U1> <#C1> <!subteam^S1> <!here> Nov 14",
+		},
+		{
+			name: "section quote and fence",
+			blocks: []slack.Block{slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType,
+				"&gt; quoted\n• item\n```a *b*```", false, false), nil, nil)},
+			want: "> quoted\n\n- item\n\n```\na *b*\n```",
+		},
+		{
+			name: "section fields",
+			blocks: []slack.Block{slack.NewSectionBlock(nil, []*slack.TextBlockObject{
+				slack.NewTextBlockObject(slack.MarkdownType, "*Status*", false, false),
+				slack.NewTextBlockObject(slack.PlainTextType, "# done", false, false),
+			}, nil)},
+			want: "**Status**\n\n\\# done",
+		},
+		{
+			name:   "divider",
+			blocks: []slack.Block{slack.NewDividerBlock()},
+			want:   "---",
+		},
+		{
+			name:   "image",
+			blocks: []slack.Block{slack.NewImageBlock(imageURL, "a chart", "", slack.NewTextBlockObject(slack.PlainTextType, "Chart", false, false))},
+			want:   "![a chart](https://example.com/a.png \"Chart\")",
+		},
+		{
+			name: "context",
+			blocks: []slack.Block{slack.NewContextBlock("",
+				&slack.ImageBlockElement{Type: slack.METImage, ImageURL: &imageURL, AltText: "icon"},
+				slack.NewTextBlockObject(slack.MarkdownType, "by <@U1>", false, false),
+			)},
+			want: "![icon](https://example.com/a.png) by <@U1>",
+		},
+		{
+			name: "actions",
+			blocks: []slack.Block{slack.NewActionBlock("",
+				slack.NewButtonBlockElement("open", "", slack.NewTextBlockObject(slack.PlainTextType, "Open", false, false)).WithURL("https://example.com"),
+				slack.NewButtonBlockElement("ack", "", slack.NewTextBlockObject(slack.PlainTextType, "Ack", false, false)),
+			)},
+			want: "[Open](https://example.com) | Ack",
+		},
+		{
+			name: "rich text inline elements",
+			blocks: []slack.Block{slack.NewRichTextBlock("", slack.NewRichTextSection(
+				slack.NewRichTextSectionTextElement("Hi ", nil),
+				slack.NewRichTextSectionUserElement("U1", nil),
+				slack.NewRichTextSectionTextElement(" see ", nil),
+				slack.NewRichTextSectionChannelElement("C1", nil),
+				slack.NewRichTextSectionTextElement(", ", nil),
+				slack.NewRichTextSectionLinkElement("https://example.com", "docs", &slack.RichTextSectionTextStyle{Bold: true}),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionEmojiElement("tada", 0, nil),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionBroadcastElement("channel"),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionUserGroupElement("S1"),
+				slack.NewRichTextSectionTextElement(" on ", nil),
+				slack.NewRichTextSectionDateElement(1700000000, "{date}", nil, &fallback),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionColorElement("#ff0000"),
+			))},
+			want: "Hi <@U1> see <#C1>, **[docs](https://example.com)** :tada: <!channel> <!subteam^S1> on Jan 2nd #ff0000",
+		},
+		{
+			name: "rich text styles",
+			blocks: []slack.Block{slack.NewRichTextBlock("", slack.NewRichTextSection(
+				slack.NewRichTextSectionTextElement("bold ", &slack.RichTextSectionTextStyle{Bold: true}),
+				slack.NewRichTextSectionTextElement("too", &slack.RichTextSectionTextStyle{Bold: true}),
+				slack.NewRichTextSectionTextElement(" and ", nil),
+				slack.NewRichTextSectionTextElement("a `tick`", &slack.RichTextSectionTextStyle{Code: true}),
+				slack.NewRichTextSectionTextElement(" ", nil),
+				slack.NewRichTextSectionTextElement("gone", &slack.RichTextSectionTextStyle{Italic: true, Strike: true}),
+			))},
+			want: "**bold too** and `` a `tick` `` *~gone~*",
+		},
+		{
+			name: "rich text lists",
+			blocks: []slack.Block{slack.NewRichTextBlock("",
+				slack.NewRichTextList(slack.RTEListOrdered, 0,
+					slack.NewRichTextSection(slack.NewRichTextSectionTextElement("one", nil)),
+				),
+				slack.NewRichTextList(slack.RTEListBullet, 1,
+					slack.NewRichTextSection(slack.NewRichTextSectionTextElement("nested", nil)),
+				),
+				&slack.RichTextList{Type: slack.RTEList, Style: slack.RTEListOrdered, Offset: 1, Elements: []slack.RichTextElement{
+					slack.NewRichTextSection(slack.NewRichTextSectionTextElement("two", nil)),
+				}},
+			)},
+			want: "1. one\n   - nested\n2. two",
+		},
+		{
+			name: "rich text quote and preformatted",
+			blocks: []slack.Block{slack.NewRichTextBlock("",
+				&slack.RichTextQuote{Type: slack.RTEQuote, Elements: []slack.RichTextSectionElement{
+					slack.NewRichTextSectionTextElement("line one\nline two", nil),
+				}},
+				&slack.RichTextPreformatted{RichTextSection: slack.RichTextSection{Type: slack.RTEPreformatted, Elements: []slack.RichTextSectionElement{
+					slack.NewRichTextSectionTextElement("fmt.Println(\"```\")", nil),
+				}}},
+			)},
+			want: "> line one\n> line two\n\n````\nfmt.Println(\"```\")\n````",
+		},
+		{
+			name: "value blocks",
+			blocks: []slack.Block{
+				slack.HeaderBlock{Type: slack.MBTHeader, Text: slack.NewTextBlockObject(slack.PlainTextType, "Title", false, false)},
+				slack.DividerBlock{Type: slack.MBTDivider},
+			},
+			want: "# Title\n\n---",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertBlocksToMarkdown(tt.blocks)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("markdown mismatch\ngot:  %q\nwant: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertBlocksToMarkdownFormats(t *testing.T) {
+	blocks := []slack.Block{slack.NewRichTextBlock("", slack.NewRichTextSection(
+		slack.NewRichTextSectionUserElement("U1", nil),
+		slack.NewRichTextSectionTextElement(" in ", nil),
+		slack.NewRichTextSectionChannelElement("C1", nil),
+		slack.NewRichTextSectionTextElement(" ", nil),
+		&slack.RichTextSectionEmojiElement{Type: slack.RTSEEmoji, Name: "tada", Unicode: "1f389"},
+	))}
+
+	got, err := ConvertBlocksToMarkdown(blocks,
+		MarkdownUserFormat(func(id string) string { return "@" + id }),
+		MarkdownChannelFormat(func(id string) string { return "#" + id }),
+		MarkdownEmojiFormat(func(name, unicode string) string { return "U+" + unicode }),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "@U1 in #C1 U+1f389"; got != want {
+		t.Errorf("markdown mismatch, got=%q, want=%q", got, want)
+	}
+}
+
+func TestConvertBlocksToMarkdownNilBlock(t *testing.T) {
+	if _, err := ConvertBlocksToMarkdown([]slack.Block{nil}); err == nil {
+		t.Errorf("expected an error for a nil block")
+	}
+}
+
+func TestConvertBlocksToMarkdownRoundTrip(t *testing.T) {
+	gfm := NewConverter(WithExtensions(extension.GFM))
+	tests := []struct {
+		name      string
+		converter *Converter
+		markdown  string
+	}{
+		{name: "paragraphs", converter: gfm, markdown: "# Weekly report\n\nThe **build** is *green* and ~~red~~ `ok`, see [docs](https://example.com).\nNext line."},
+		{name: "quote", converter: gfm, markdown: "> quoted **bold**"},
+		{name: "quote with inline markup", converter: gfm, markdown: "> a *b* ~~c~~ `d` [e](https://example.com)\n> next line"},
+		{name: "quote paragraphs", converter: gfm, markdown: "> one\n>\n> **two**"},
+		{name: "quote with escapes", converter: gfm, markdown: "> 2 \\* 3 and \\*not bold\\*"},
+		{name: "strike", converter: gfm, markdown: "~~gone~~ and ~one~ and **~~both~~**"},
+		{name: "strike without GFM", converter: defaultConverter, markdown: "~gone~ and *~both~*"},
+		{name: "strike in lists", converter: gfm, markdown: "- ~~gone~~\n- **~~both~~**"},
+		{name: "lists", converter: gfm, markdown: "- one\n- **two**\n  - nested\n    1. deep\n- three"},
+		{name: "ordered list and rule", converter: gfm, markdown: "1. first\n2. second\n\n---\n\nText with <@U123> and 2 * 3"},
+		{name: "task list", converter: gfm, markdown: "- [x] done\n- [ ] todo"},
+		{name: "code", converter: gfm, markdown: "```\nfunc main() {}\n```"},
+		{name: "code with backticks", converter: defaultConverter, markdown: "````\n```go\n```\n````\n\nRun `go test`"},
+		{name: "table", converter: gfm, markdown: "| Name | Score |\n|:-----|------:|\n| a | 1 |\n| b | |"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.converter.ConvertMarkdownTextToBlocks(tt.markdown)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			back, err := ConvertBlocksToMarkdown(want)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := tt.converter.ConvertMarkdownTextToBlocks(back)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.Marshal(got)
+				wantJSON, _ := json.Marshal(want)
+				t.Errorf("round trip changed the blocks, markdown=%q\ngot:  %s\nwant: %s", back, gotJSON, wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/slack-go/slack"
+)
+
+// UpdateOption configures UpdateMarkdown.
+type UpdateOption func(*updateConfig)
+
+type updateConfig struct {
+	postConfig
+	previous *string
+}
+
+// UpdatePostOptions sets how messages are posted and edited, with the options of
+// PostMarkdown such as PostOverflow and PostMessageOptions.
+func UpdatePostOptions(opts ...PostOption) UpdateOption {
+	return func(c *updateConfig) {
+		for _, opt := range opts {
+			opt(&c.postConfig)
+		}
+	}
+}
+
+// UpdatePreviousMarkdown sets the markdown the messages given to UpdateMarkdown were
+// posted from, so that the messages whose content did not change are left alone.
+func UpdatePreviousMarkdown(markdown string) UpdateOption {
+	return func(c *updateConfig) {
+		c.previous = &markdown
+	}
+}
+
+// UpdateMarkdown edits the messages of a document posted with PostMarkdown to show
+// new markdown. timestamps are the messages of the document in order. Messages are
+// updated in place with chat.update, messages the new markdown needs in addition are
+// posted like PostMarkdown posts its overflow, and messages it no longer needs are
+// deleted from the end. With UpdatePreviousMarkdown, messages whose content did not
+// change are not updated.
+//
+// It returns the timestamps of the document's messages after the edit. If editing
+// fails, they reflect the messages posted and deleted before the failure.
+func UpdateMarkdown(ctx context.Context, client *slack.Client, channelID string, timestamps []string, markdown string, opts ...UpdateOption) ([]string, error) {
+	return defaultConverter.UpdateMarkdown(ctx, client, channelID, timestamps, markdown, opts...)
+}
+
+// UpdateMarkdown edits the messages of a document to show new markdown converted
+// with the converter's settings.
+func (c *Converter) UpdateMarkdown(ctx context.Context, client *slack.Client, channelID string, timestamps []string, markdown string, opts ...UpdateOption) ([]string, error) {
+	cfg := updateConfig{postConfig: postConfig{overflow: OverflowThread}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	chunks, err := c.ConvertMarkdownTextToBlockChunks(markdown)
+	if err != nil {
+		return timestamps, err
+	}
+	if len(chunks) == 0 {
+		return timestamps, ErrEmptyMessage
+	}
+
+	var previous []string
+	if cfg.previous != nil {
+		if previous, err = c.chunkSignatures(*cfg.previous); err != nil {
+			return timestamps, err
+		}
+	}
+
+	current := slices.Clone(timestamps)
+	threadTS := cfg.threadTS
+	if threadTS == "" && cfg.overflow == OverflowThread && len(current) > 0 {
+		threadTS = current[0]
+	}
+
+	for i, chunk := range chunks {
+		msgOpts := c.chunkMessageOptions(markdown, i, chunk)
+
+		if i < len(current) {
+			if i < len(previous) {
+				signature, err := c.chunkSignature(markdown, i, chunk)
+				if err != nil {
+					return current, err
+				}
+				if signature == previous[i] {
+					continue
+				}
+			}
+			if _, _, _, err := client.UpdateMessageContext(ctx, channelID, current[i], msgOpts...); err != nil {
+				return current, err
+			}
+			continue
+		}
+
+		msgOpts = append(msgOpts, cfg.msgOptions...)
+		if threadTS != "" {
+			msgOpts = append(msgOpts, slack.MsgOptionTS(threadTS))
+		}
+		_, ts, err := client.PostMessageContext(ctx, channelID, msgOpts...)
+		if err != nil {
+			return current, err
+		}
+		current = append(current, ts)
+		if i == 0 && threadTS == "" && cfg.overflow == OverflowThread {
+			threadTS = ts
+		}
+	}
+
+	for len(current) > len(chunks) {
+		last := current[len(current)-1]
+		if _, _, err := client.DeleteMessageContext(ctx, channelID, last); err != nil {
+			return current, err
+		}
+		current = current[:len(current)-1]
+	}
+	return current, nil
+}
+
+// chunkSignatures returns the signatures of the messages markdown is posted as.
+func (c *Converter) chunkSignatures(markdown string) ([]string, error) {
+	chunks, err := c.ConvertMarkdownTextToBlockChunks(markdown)
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		if signatures[i], err = c.chunkSignature(markdown, i, chunk); err != nil {
+			return nil, err
+		}
+	}
+	return signatures, nil
+}
+
+// chunkSignature returns the text and blocks JSON of the i-th message of markdown,
+// which are equal for messages with the same content.
+func (c *Converter) chunkSignature(markdown string, i int, chunk []slack.Block) (string, error) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return "", err
+	}
+	return c.chunkFallbackText(markdown, i, chunk) + "\x00" + string(data), nil
+}
@@ -0,0 +1,122 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUpdateMarkdown(t *testing.T) {
+	c := NewConverter(WithBlocksPerMessage(1))
+	previous := []string{"1600000000.000001", "1600000000.000002", "1600000000.000003"}
+
+	tests := []struct {
+		name      string
+		markdown  string
+		opts      []UpdateOption
+		want      []string
+		wantCalls []string
+	}{
+		{
+			name:      "same number of messages",
+			markdown:  "one\n\ntwo\n\nthree",
+			want:      previous,
+			wantCalls: []string{"chat.update 1600000000.000001", "chat.update 1600000000.000002", "chat.update 1600000000.000003"},
+		},
+		{
+			name:      "unchanged messages",
+			markdown:  "one\n\n2\n\nthree",
+			opts:      []UpdateOption{UpdatePreviousMarkdown("one\n\ntwo\n\nthree")},
+			want:      previous,
+			wantCalls: []string{"chat.update 1600000000.000001", "chat.update 1600000000.000002"},
+		},
+		{
+			name:     "more messages",
+			markdown: "one\n\ntwo\n\nthree\n\nfour\n\nfive",
+			want:     append(previous, "1700000000.000001", "1700000000.000002"),
+			wantCalls: []string{
+				"chat.update 1600000000.000001", "chat.update 1600000000.000002", "chat.update 1600000000.000003",
+				"chat.postMessage thread_ts=1600000000.000001", "chat.postMessage thread_ts=1600000000.000001",
+			},
+		},
+		{
+			name:     "more follow-up messages",
+			markdown: "one\n\ntwo\n\nthree\n\nfour",
+			opts:     []UpdateOption{UpdatePostOptions(PostOverflow(OverflowMessages))},
+			want:     append(previous, "1700000000.000001"),
+			wantCalls: []string{
+				"chat.update 1600000000.000001", "chat.update 1600000000.000002", "chat.update 1600000000.000003",
+				"chat.postMessage thread_ts=",
+			},
+		},
+		{
+			name:     "fewer messages",
+			markdown: "one",
+			opts:     []UpdateOption{UpdatePreviousMarkdown("one\n\ntwo\n\nthree")},
+			want:     previous[:1],
+			// the fallback text of the first message summarizes the whole document
+			wantCalls: []string{"chat.update 1600000000.000001", "chat.delete 1600000000.000003", "chat.delete 1600000000.000002"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, calls := newPostServer(t, nil)
+			got, err := c.UpdateMarkdown(context.Background(), client, "C1", previous, tt.markdown, tt.opts...)
+			if err != nil {
+				t.Fatalf("UpdateMarkdown() error = %v", err)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("timestamps = %q, want %q", got, tt.want)
+			}
+
+			var gotCalls []string
+			for _, call := range calls() {
+				if call.method == "chat.postMessage" {
+					gotCalls = append(gotCalls, call.method+" thread_ts="+call.form.Get("thread_ts"))
+				} else {
+					gotCalls = append(gotCalls, call.method+" "+call.form.Get("ts"))
+				}
+				if channel := call.form.Get("channel"); channel != "C1" {
+					t.Errorf("%s channel = %q", call.method, channel)
+				}
+			}
+			if strings.Join(gotCalls, "\n") != strings.Join(tt.wantCalls, "\n") {
+				t.Errorf("calls = %q, want %q", gotCalls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestUpdateMarkdownFirstMessage(t *testing.T) {
+	client, calls := newPostServer(t, nil)
+	got, err := UpdateMarkdown(context.Background(), client, "C1", nil, "# Title\n\ntext")
+	if err != nil {
+		t.Fatalf("UpdateMarkdown() error = %v", err)
+	}
+	if len(got) != 1 || got[0] != "1700000000.000001" {
+		t.Errorf("timestamps = %q", got)
+	}
+	if form := calls()[0].form; form.Get("thread_ts") != "" || form.Get("text") != "Title\ntext" {
+		t.Errorf("posted %v", form)
+	}
+}
+
+func TestUpdateMarkdownErrors(t *testing.T) {
+	c := NewConverter(WithBlocksPerMessage(1))
+	previous := []string{"1600000000.000001", "1600000000.000002", "1600000000.000003"}
+
+	client, _ := newPostServer(t, failAt(3))
+	got, err := c.UpdateMarkdown(context.Background(), client, "C1", previous, "one")
+	if err == nil || err.Error() != "rate_limited" {
+		t.Errorf("UpdateMarkdown() error = %v, want rate_limited", err)
+	}
+	if want := previous[:2]; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("timestamps = %q, want %q", got, want)
+	}
+
+	if got, err := c.UpdateMarkdown(context.Background(), client, "C1", previous, ""); !errors.Is(err, ErrEmptyMessage) || len(got) != 3 {
+		t.Errorf("UpdateMarkdown() = %q, %v, want the previous timestamps and ErrEmptyMessage", got, err)
+	}
+}
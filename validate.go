@@ -0,0 +1,436 @@
+package util
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// MaxSectionFields is the maximum number of fields in a section block.
+	MaxSectionFields = 10
+	// MaxSectionFieldLength is the maximum number of characters in a section field.
+	MaxSectionFieldLength = 2000
+	// MaxContextElements is the maximum number of elements in a context block.
+	MaxContextElements = 10
+	// MaxAltTextLength is the maximum number of characters in an image's alt text.
+	MaxAltTextLength = 2000
+	// MaxButtonTextLength is the maximum number of characters in a button's text.
+	MaxButtonTextLength = 75
+	// MaxURLLength is the maximum number of characters in an image or button URL.
+	MaxURLLength = 3000
+	// MaxBlockIDLength is the maximum number of characters in a block_id.
+	MaxBlockIDLength = 255
+	// MaxTableRows is the maximum number of rows in a table block.
+	MaxTableRows = 100
+	// MaxTableColumns is the maximum number of cells in a row of a table block.
+	MaxTableColumns = 20
+)
+
+// ValidationError describes a block that violates a Block Kit rule.
+type ValidationError struct {
+	// BlockIndex is the index of the offending block, or -1 for errors about the whole slice.
+	BlockIndex int
+	// Path is a JSON path to the offending field, such as "blocks[2].text.text".
+	Path string
+	// Message describes the violated rule.
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateOption configures ValidateBlocks.
+type ValidateOption func(*validateConfig)
+
+type validateConfig struct {
+	maxBlocks int
+}
+
+// ValidateMaxBlocks sets the maximum number of blocks ValidateBlocks accepts.
+// Use MaxMessageBlocks for messages and MaxSurfaceBlocks for modals and home tabs.
+func ValidateMaxBlocks(n int) ValidateOption {
+	return func(c *validateConfig) {
+		if n > 0 {
+			c.maxBlocks = n
+		}
+	}
+}
+
+// ValidateBlocks checks blocks against Block Kit rules and returns every violation
+// found, or nil if the blocks are valid. It covers text lengths, the block count,
+// empty text objects, duplicate block_ids, invalid URLs, empty rich_text sections,
+// the plain_text and emoji rules of headers, and the size of tables.
+func ValidateBlocks(blocks []slack.Block, opts ...ValidateOption) []ValidationError {
+	cfg := validateConfig{maxBlocks: MaxMessageBlocks}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	v := &validator{blockIDs: map[string]int{}}
+
+	if len(blocks) > cfg.maxBlocks {
+		v.add(-1, "blocks", "must not contain more than %d blocks, got %d", cfg.maxBlocks, len(blocks))
+	}
+
+	for i, block := range blocks {
+		v.validateBlock(i, fmt.Sprintf("blocks[%d]", i), block)
+	}
+
+	return v.errs
+}
+
+type validator struct {
+	errs     []ValidationError
+	blockIDs map[string]int
+	table    int
+	hasTable bool
+}
+
+func (v *validator) add(index int, path string, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{
+		BlockIndex: index,
+		Path:       path,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validateBlock(index int, path string, block slack.Block) {
+	if isNil(block) {
+		v.add(index, path, "must not be null")
+		return
+	}
+
+	if id := block.ID(); id != "" {
+		if utf8.RuneCountInString(id) > MaxBlockIDLength {
+			v.add(index, path+".block_id", "must not be longer than %d characters", MaxBlockIDLength)
+		}
+		if first, ok := v.blockIDs[id]; ok {
+			v.add(index, path+".block_id", "duplicates the block_id of blocks[%d]", first)
+		} else {
+			v.blockIDs[id] = index
+		}
+	}
+
+	switch b := block.(type) {
+	case *slack.HeaderBlock:
+		v.validateHeader(index, path, b)
+	case slack.HeaderBlock:
+		v.validateHeader(index, path, &b)
+	case *slack.SectionBlock:
+		v.validateSection(index, path, b)
+	case slack.SectionBlock:
+		v.validateSection(index, path, &b)
+	case *slack.RichTextBlock:
+		v.validateRichText(index, path, b)
+	case slack.RichTextBlock:
+		v.validateRichText(index, path, &b)
+	case *slack.ImageBlock:
+		v.validateImage(index, path, b)
+	case slack.ImageBlock:
+		v.validateImage(index, path, &b)
+	case *slack.ContextBlock:
+		v.validateContext(index, path, b)
+	case slack.ContextBlock:
+		v.validateContext(index, path, &b)
+	case *slack.ActionBlock:
+		v.validateActions(index, path, b)
+	case slack.ActionBlock:
+		v.validateActions(index, path, &b)
+	case *slack.TableBlock:
+		v.validateTable(index, path, b)
+	case slack.TableBlock:
+		v.validateTable(index, path, &b)
+	}
+}
+
+func (v *validator) validateHeader(index int, path string, b *slack.HeaderBlock) {
+	if b.Text == nil {
+		v.add(index, path+".text", "is required")
+		return
+	}
+	if b.Text.Type != slack.PlainTextType {
+		v.add(index, path+".text.type", "must be %q, got %q", slack.PlainTextType, b.Text.Type)
+	}
+	v.validateText(index, path+".text", b.Text, MaxHeaderTextLength)
+}
+
+func (v *validator) validateSection(index int, path string, b *slack.SectionBlock) {
+	if b.Text == nil && len(b.Fields) == 0 {
+		v.add(index, path, "must have text or fields")
+	}
+	if b.Text != nil {
+		v.validateText(index, path+".text", b.Text, MaxSectionTextLength)
+	}
+	if len(b.Fields) > MaxSectionFields {
+		v.add(index, path+".fields", "must not contain more than %d fields, got %d", MaxSectionFields, len(b.Fields))
+	}
+	for i, field := range b.Fields {
+		fieldPath := fmt.Sprintf("%s.fields[%d]", path, i)
+		if field == nil {
+			v.add(index, fieldPath, "must not be null")
+			continue
+		}
+		v.validateText(index, fieldPath, field, MaxSectionFieldLength)
+	}
+	if b.Accessory != nil {
+		if b.Accessory.ButtonElement != nil {
+			v.validateButton(index, path+".accessory", b.Accessory.ButtonElement)
+		}
+		if b.Accessory.ImageElement != nil {
+			v.validateImageElement(index, path+".accessory", b.Accessory.ImageElement)
+		}
+	}
+}
+
+func (v *validator) validateRichText(index int, path string, b *slack.RichTextBlock) {
+	if len(b.Elements) == 0 {
+		v.add(index, path+".elements", "must not be empty")
+	}
+	for i, elem := range b.Elements {
+		v.validateRichTextElement(index, fmt.Sprintf("%s.elements[%d]", path, i), elem)
+	}
+}
+
+func (v *validator) validateRichTextElement(index int, path string, elem slack.RichTextElement) {
+	if isNil(elem) {
+		v.add(index, path, "must not be null")
+		return
+	}
+	switch e := elem.(type) {
+	case *slack.RichTextSection:
+		v.validateRichTextSectionElements(index, path, e.Elements)
+	case *slack.RichTextQuote:
+		v.validateRichTextSectionElements(index, path, e.Elements)
+	case *slack.RichTextPreformatted:
+		v.validateRichTextSectionElements(index, path, e.Elements)
+	case *slack.RichTextList:
+		if len(e.Elements) == 0 {
+			v.add(index, path+".elements", "must not be empty")
+		}
+		for i, item := range e.Elements {
+			v.validateRichTextElement(index, fmt.Sprintf("%s.elements[%d]", path, i), item)
+		}
+	}
+}
+
+func (v *validator) validateRichTextSectionElements(index int, path string, elems []slack.RichTextSectionElement) {
+	if len(elems) == 0 {
+		v.add(index, path+".elements", "must not be empty")
+		return
+	}
+
+	empty := true
+	for i, elem := range elems {
+		elemPath := fmt.Sprintf("%s.elements[%d]", path, i)
+		if isNil(elem) {
+			v.add(index, elemPath, "must not be null")
+			continue
+		}
+		switch e := elem.(type) {
+		case *slack.RichTextSectionTextElement:
+			if e.Text != "" {
+				empty = false
+			}
+		case *slack.RichTextSectionLinkElement:
+			empty = false
+			if !isValidURL(e.URL) {
+				v.add(index, elemPath+".url", "must be a valid absolute URL, got %q", e.URL)
+			}
+		default:
+			empty = false
+		}
+	}
+	if empty {
+		v.add(index, path+".elements", "must not contain only empty text")
+	}
+}
+
+func (v *validator) validateTable(index int, path string, b *slack.TableBlock) {
+	if v.hasTable {
+		v.add(index, path, "must be the only table of the message, blocks[%d] is a table", v.table)
+	} else {
+		v.table, v.hasTable = index, true
+	}
+
+	if len(b.Rows) == 0 {
+		v.add(index, path+".rows", "must not be empty")
+	}
+	if len(b.Rows) > MaxTableRows {
+		v.add(index, path+".rows", "must not contain more than %d rows, got %d", MaxTableRows, len(b.Rows))
+	}
+	if len(b.ColumnSettings) > MaxTableColumns {
+		v.add(index, path+".column_settings", "must not contain more than %d columns, got %d", MaxTableColumns, len(b.ColumnSettings))
+	}
+	for i, row := range b.Rows {
+		rowPath := fmt.Sprintf("%s.rows[%d]", path, i)
+		if len(row) == 0 {
+			v.add(index, rowPath, "must not be empty")
+		}
+		if len(row) > MaxTableColumns {
+			v.add(index, rowPath, "must not contain more than %d cells, got %d", MaxTableColumns, len(row))
+		}
+		for j, cell := range row {
+			cellPath := fmt.Sprintf("%s[%d]", rowPath, j)
+			if cell == nil {
+				v.add(index, cellPath, "must not be null")
+				continue
+			}
+			v.validateRichText(index, cellPath, cell)
+		}
+	}
+}
+
+func (v *validator) validateImage(index int, path string, b *slack.ImageBlock) {
+	if b.ImageURL == "" && b.SlackFile == nil {
+		v.add(index, path, "must have image_url or slack_file")
+	}
+	if b.ImageURL != "" {
+		v.validateURL(index, path+".image_url", b.ImageURL)
+	}
+	if b.AltText == "" {
+		v.add(index, path+".alt_text", "must not be empty")
+	} else if utf8.RuneCountInString(b.AltText) > MaxAltTextLength {
+		v.add(index, path+".alt_text", "must not be longer than %d characters", MaxAltTextLength)
+	}
+	if b.Title != nil {
+		if b.Title.Type != slack.PlainTextType {
+			v.add(index, path+".title.type", "must be %q, got %q", slack.PlainTextType, b.Title.Type)
+		}
+		v.validateText(index, path+".title", b.Title, MaxAltTextLength)
+	}
+}
+
+func (v *validator) validateImageElement(index int, path string, e *slack.ImageBlockElement) {
+	if e == nil {
+		v.add(index, path, "must not be null")
+		return
+	}
+	if e.ImageURL == nil && e.SlackFile == nil {
+		v.add(index, path, "must have image_url or slack_file")
+	}
+	if e.ImageURL != nil {
+		v.validateURL(index, path+".image_url", *e.ImageURL)
+	}
+	if e.AltText == "" {
+		v.add(index, path+".alt_text", "must not be empty")
+	}
+}
+
+func (v *validator) validateContext(index int, path string, b *slack.ContextBlock) {
+	elems := b.ContextElements.Elements
+	if len(elems) == 0 {
+		v.add(index, path+".elements", "must not be empty")
+	}
+	if len(elems) > MaxContextElements {
+		v.add(index, path+".elements", "must not contain more than %d elements, got %d", MaxContextElements, len(elems))
+	}
+	for i, elem := range elems {
+		elemPath := fmt.Sprintf("%s.elements[%d]", path, i)
+		switch e := elem.(type) {
+		case *slack.TextBlockObject:
+			v.validateText(index, elemPath, e, MaxSectionTextLength)
+		case *slack.ImageBlockElement:
+			v.validateImageElement(index, elemPath, e)
+		case nil:
+			v.add(index, elemPath, "must not be null")
+		}
+	}
+}
+
+func (v *validator) validateActions(index int, path string, b *slack.ActionBlock) {
+	if b.Elements == nil || len(b.Elements.ElementSet) == 0 {
+		v.add(index, path+".elements", "must not be empty")
+		return
+	}
+	for i, elem := range b.Elements.ElementSet {
+		elemPath := fmt.Sprintf("%s.elements[%d]", path, i)
+		switch e := elem.(type) {
+		case *slack.ButtonBlockElement:
+			v.validateButton(index, elemPath, e)
+		case nil:
+			v.add(index, elemPath, "must not be null")
+		}
+	}
+}
+
+func (v *validator) validateButton(index int, path string, e *slack.ButtonBlockElement) {
+	if e == nil {
+		v.add(index, path, "must not be null")
+		return
+	}
+	if e.Text == nil {
+		v.add(index, path+".text", "is required")
+	} else {
+		if e.Text.Type != slack.PlainTextType {
+			v.add(index, path+".text.type", "must be %q, got %q", slack.PlainTextType, e.Text.Type)
+		}
+		v.validateText(index, path+".text", e.Text, MaxButtonTextLength)
+	}
+	if e.URL != "" {
+		v.validateURL(index, path+".url", e.URL)
+	}
+}
+
+// validateText checks a text object's type, emptiness, emoji flag and length.
+// Typed nil objects, such as a nil *slack.TextBlockObject in a context block's
+// elements, are reported instead of dereferenced.
+func (v *validator) validateText(index int, path string, t *slack.TextBlockObject, limit int) {
+	if t == nil {
+		v.add(index, path, "must not be null")
+		return
+	}
+	switch t.Type {
+	case slack.PlainTextType:
+	case slack.MarkdownType:
+		if t.Emoji != nil {
+			v.add(index, path+".emoji", "must not be set on %q text", slack.MarkdownType)
+		}
+	default:
+		v.add(index, path+".type", "must be %q or %q, got %q", slack.PlainTextType, slack.MarkdownType, t.Type)
+	}
+
+	if t.Text == "" {
+		v.add(index, path+".text", "must not be empty")
+	} else if n := utf8.RuneCountInString(t.Text); n > limit {
+		v.add(index, path+".text", "must not be longer than %d characters, got %d", limit, n)
+	}
+}
+
+func (v *validator) validateURL(index int, path string, rawURL string) {
+	if utf8.RuneCountInString(rawURL) > MaxURLLength {
+		v.add(index, path, "must not be longer than %d characters", MaxURLLength)
+	}
+	if !isValidURL(rawURL) {
+		v.add(index, path, "must be a valid absolute URL, got %q", rawURL)
+	}
+}
+
+// isNil reports whether v is nil or a nil pointer stored in an interface, which
+// callers building blocks by hand can pass by accident.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// isValidURL reports whether rawURL is an absolute URL Slack can link to.
+func isValidURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto", "tel":
+		return u.Opaque != "" || u.Path != ""
+	}
+	return u.Host != "" || u.Opaque != ""
+}
@@ -0,0 +1,264 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/slack-go/slack"
+)
+
+func TestValidateBlocks(t *testing.T) {
+	plain := func(text string) *slack.TextBlockObject {
+		return &slack.TextBlockObject{Type: slack.PlainTextType, Text: text}
+	}
+	mrkdwn := func(text string) *slack.TextBlockObject {
+		return &slack.TextBlockObject{Type: slack.MarkdownType, Text: text}
+	}
+	section := func(text string) *slack.SectionBlock {
+		return &slack.SectionBlock{Type: slack.MBTSection, Text: mrkdwn(text)}
+	}
+	richText := func(elements ...slack.RichTextSectionElement) *slack.RichTextBlock {
+		return &slack.RichTextBlock{
+			Type: slack.MBTRichText,
+			Elements: []slack.RichTextElement{
+				&slack.RichTextSection{Type: slack.RTESection, Elements: elements},
+			},
+		}
+	}
+
+	table := func(rows, columns int) *slack.TableBlock {
+		block := slack.NewTableBlock("")
+		for range rows {
+			var cells []*slack.RichTextBlock
+			for range columns {
+				cells = append(cells, richText(&slack.RichTextSectionTextElement{Type: slack.RTSEText, Text: "cell"}))
+			}
+			block.AddRow(cells...)
+		}
+		return block
+	}
+
+	tests := []struct {
+		name       string
+		blocks     []slack.Block
+		opts       []ValidateOption
+		wantPaths  []string
+		wantIndexs []int
+	}{
+		{
+			name: "valid",
+			blocks: []slack.Block{
+				&slack.HeaderBlock{Type: slack.MBTHeader, Text: plain("Title")},
+				section("Body"),
+				richText(&slack.RichTextSectionLinkElement{Type: slack.RTSELink, URL: "https://example.com"}),
+				slack.NewDividerBlock(),
+			},
+		},
+		{
+			name:       "too many blocks",
+			blocks:     []slack.Block{section("a"), section("b"), section("c")},
+			opts:       []ValidateOption{ValidateMaxBlocks(2)},
+			wantPaths:  []string{"blocks"},
+			wantIndexs: []int{-1},
+		},
+		{
+			name:       "long section text",
+			blocks:     []slack.Block{section(strings.Repeat("a", MaxSectionTextLength+1))},
+			wantPaths:  []string{"blocks[0].text.text"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "long header text",
+			blocks: []slack.Block{
+				section("ok"),
+				&slack.HeaderBlock{Type: slack.MBTHeader, Text: plain(strings.Repeat("a", MaxHeaderTextLength+1))},
+			},
+			wantPaths:  []string{"blocks[1].text.text"},
+			wantIndexs: []int{1},
+		},
+		{
+			name:       "empty text",
+			blocks:     []slack.Block{section("")},
+			wantPaths:  []string{"blocks[0].text.text"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "mrkdwn header with emoji",
+			blocks: []slack.Block{
+				&slack.HeaderBlock{
+					Type: slack.MBTHeader,
+					Text: &slack.TextBlockObject{Type: slack.MarkdownType, Text: "Title", Emoji: &boolTrue},
+				},
+			},
+			wantPaths:  []string{"blocks[0].text.type", "blocks[0].text.emoji"},
+			wantIndexs: []int{0, 0},
+		},
+		{
+			name: "duplicate block_id",
+			blocks: []slack.Block{
+				&slack.SectionBlock{Type: slack.MBTSection, BlockID: "a", Text: mrkdwn("one")},
+				&slack.SectionBlock{Type: slack.MBTSection, BlockID: "b", Text: mrkdwn("two")},
+				&slack.SectionBlock{Type: slack.MBTSection, BlockID: "a", Text: mrkdwn("three")},
+			},
+			wantPaths:  []string{"blocks[2].block_id"},
+			wantIndexs: []int{2},
+		},
+		{
+			name: "invalid link url",
+			blocks: []slack.Block{
+				richText(&slack.RichTextSectionLinkElement{Type: slack.RTSELink, URL: "/relative/path"}),
+			},
+			wantPaths:  []string{"blocks[0].elements[0].elements[0].url"},
+			wantIndexs: []int{0},
+		},
+		{
+			name:       "empty rich_text section",
+			blocks:     []slack.Block{richText()},
+			wantPaths:  []string{"blocks[0].elements[0].elements"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "rich_text section with only empty text",
+			blocks: []slack.Block{
+				richText(&slack.RichTextSectionTextElement{Type: slack.RTSEText, Text: ""}),
+			},
+			wantPaths:  []string{"blocks[0].elements[0].elements"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "nested list item",
+			blocks: []slack.Block{
+				&slack.RichTextBlock{
+					Type: slack.MBTRichText,
+					Elements: []slack.RichTextElement{
+						&slack.RichTextList{
+							Type:  slack.RTEList,
+							Style: slack.RTEListBullet,
+							Elements: []slack.RichTextElement{
+								&slack.RichTextSection{Type: slack.RTESection},
+							},
+						},
+					},
+				},
+			},
+			wantPaths:  []string{"blocks[0].elements[0].elements[0].elements"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "section without text",
+			blocks: []slack.Block{
+				&slack.SectionBlock{Type: slack.MBTSection},
+			},
+			wantPaths:  []string{"blocks[0]"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "image without alt text",
+			blocks: []slack.Block{
+				&slack.ImageBlock{Type: slack.MBTImage, ImageURL: "https://example.com/a.png"},
+			},
+			wantPaths:  []string{"blocks[0].alt_text"},
+			wantIndexs: []int{0},
+		},
+		{
+			name:   "valid table",
+			blocks: []slack.Block{table(MaxTableRows, MaxTableColumns)},
+		},
+		{
+			name:       "too many table rows",
+			blocks:     []slack.Block{table(MaxTableRows+1, 2)},
+			wantPaths:  []string{"blocks[0].rows"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "too many table columns",
+			blocks: []slack.Block{
+				table(1, MaxTableColumns+1).WithColumnSettings(make([]slack.ColumnSetting, MaxTableColumns+1)...),
+			},
+			wantPaths:  []string{"blocks[0].column_settings", "blocks[0].rows[0]"},
+			wantIndexs: []int{0, 0},
+		},
+		{
+			name: "invalid table cells",
+			blocks: []slack.Block{
+				slack.NewTableBlock("").AddRow(nil, richText()).AddRow(),
+			},
+			wantPaths:  []string{"blocks[0].rows[0][0]", "blocks[0].rows[0][1].elements[0].elements", "blocks[0].rows[1]"},
+			wantIndexs: []int{0, 0, 0},
+		},
+		{
+			name:       "second table",
+			blocks:     []slack.Block{table(1, 1), section("between"), table(1, 1)},
+			wantPaths:  []string{"blocks[2]"},
+			wantIndexs: []int{2},
+		},
+		{
+			name:       "empty table",
+			blocks:     []slack.Block{slack.NewTableBlock("")},
+			wantPaths:  []string{"blocks[0].rows"},
+			wantIndexs: []int{0},
+		},
+		{
+			name: "nil elements",
+			blocks: []slack.Block{
+				slack.NewContextBlock("", (*slack.TextBlockObject)(nil), (*slack.ImageBlockElement)(nil), nil),
+				slack.NewActionBlock("", (*slack.ButtonBlockElement)(nil)),
+				&slack.SectionBlock{Type: slack.MBTSection, Fields: []*slack.TextBlockObject{nil}},
+				(*slack.SectionBlock)(nil),
+				&slack.RichTextBlock{
+					Type: slack.MBTRichText,
+					Elements: []slack.RichTextElement{
+						(*slack.RichTextSection)(nil),
+						&slack.RichTextSection{
+							Type:     slack.RTESection,
+							Elements: []slack.RichTextSectionElement{(*slack.RichTextSectionTextElement)(nil), &slack.RichTextSectionTextElement{Text: "ok"}},
+						},
+					},
+				},
+			},
+			wantPaths: []string{
+				"blocks[0].elements[0]",
+				"blocks[0].elements[1]",
+				"blocks[0].elements[2]",
+				"blocks[1].elements[0]",
+				"blocks[2].fields[0]",
+				"blocks[3]",
+				"blocks[4].elements[0]",
+				"blocks[4].elements[1].elements[0]",
+			},
+			wantIndexs: []int{0, 0, 0, 1, 2, 3, 4, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateBlocks(tt.blocks, tt.opts...)
+
+			if len(got) != len(tt.wantPaths) {
+				t.Fatalf("error count mismatch, got=%v, want=%v", got, tt.wantPaths)
+			}
+			for i, err := range got {
+				if err.Path != tt.wantPaths[i] {
+					t.Errorf("path mismatch at index=%d, got=%v, want=%v", i, err.Path, tt.wantPaths[i])
+				}
+				if err.BlockIndex != tt.wantIndexs[i] {
+					t.Errorf("block index mismatch at index=%d, got=%v, want=%v", i, err.BlockIndex, tt.wantIndexs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateBlocksConvertedMarkdown(t *testing.T) {
+	markdown := "# Title\n\nParagraph with [link](https://example.com).\n\n- a\n  - b\n\n```\ncode\n```\n\n> quote\n\n" +
+		strings.Repeat("long paragraph text. ", 300)
+
+	blocks, err := ConvertMarkdownTextToBlocks(markdown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if errs := ValidateBlocks(blocks); errs != nil {
+		t.Errorf("converted blocks are invalid: %v", errs)
+	}
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+// WebhookOption configures the messages of ConvertMarkdownTextToWebhookMessages.
+type WebhookOption func(*webhookConfig)
+
+type webhookConfig struct {
+	responseType    string
+	replaceOriginal bool
+	deleteOriginal  bool
+}
+
+// WebhookResponseType sets the response_type of messages sent to a response_url,
+// slack.ResponseTypeEphemeral or slack.ResponseTypeInChannel. Incoming webhooks
+// ignore it.
+func WebhookResponseType(responseType string) WebhookOption {
+	return func(c *webhookConfig) {
+		c.responseType = responseType
+	}
+}
+
+// WebhookReplaceOriginal sets whether the first message replaces the message a
+// response_url belongs to.
+func WebhookReplaceOriginal(replace bool) WebhookOption {
+	return func(c *webhookConfig) {
+		c.replaceOriginal = replace
+	}
+}
+
+// WebhookDeleteOriginal sets whether the first message deletes the message a
+// response_url belongs to.
+func WebhookDeleteOriginal(remove bool) WebhookOption {
+	return func(c *webhookConfig) {
+		c.deleteOriginal = remove
+	}
+}
+
+// ConvertMarkdownTextToWebhookMessages converts a markdown text to the payloads of an
+// incoming webhook or a slash command's response_url, split into as many messages as
+// the blocks need. The first message gets the fallback text of the whole document, and
+// the others the plain text of their own blocks. Only the first message replaces or
+// deletes the original message, so that the others follow it.
+func ConvertMarkdownTextToWebhookMessages(markdown string, opts ...WebhookOption) ([]*slack.WebhookMessage, error) {
+	return defaultConverter.ConvertMarkdownTextToWebhookMessages(markdown, opts...)
+}
+
+// ConvertMarkdownTextToWebhookMessages converts a markdown text to webhook payloads
+// with the converter's settings and at most its blocks per message.
+func (c *Converter) ConvertMarkdownTextToWebhookMessages(markdown string, opts ...WebhookOption) ([]*slack.WebhookMessage, error) {
+	var cfg webhookConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	chunks, err := c.ConvertMarkdownTextToBlockChunks(markdown)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, ErrEmptyMessage
+	}
+
+	msgs := make([]*slack.WebhookMessage, len(chunks))
+	for i, chunk := range chunks {
+		msgs[i] = &slack.WebhookMessage{
+			Text:         c.chunkFallbackText(markdown, i, chunk),
+			Blocks:       &slack.Blocks{BlockSet: chunk},
+			ResponseType: cfg.responseType,
+		}
+		if i == 0 {
+			msgs[i].ReplaceOriginal = cfg.replaceOriginal
+			msgs[i].DeleteOriginal = cfg.deleteOriginal
+		}
+	}
+	return msgs, nil
+}
+
+// SendOption configures SendWebhookMessages.
+type SendOption func(*sendConfig)
+
+type sendConfig struct {
+	client     *http.Client
+	maxRetries int
+}
+
+// SendHTTPClient sets the HTTP client messages are sent with. The default is http.DefaultClient.
+func SendHTTPClient(client *http.Client) SendOption {
+	return func(c *sendConfig) {
+		if client != nil {
+			c.client = client
+		}
+	}
+}
+
+// SendMaxRetries sets how many times a rate limited message is retried. The default is 3.
+func SendMaxRetries(n int) SendOption {
+	return func(c *sendConfig) {
+		if n >= 0 {
+			c.maxRetries = n
+		}
+	}
+}
+
+// SendWebhookMessages posts messages in order to an incoming webhook or response_url.
+// When Slack rate limits a message with 429 Too Many Requests, it waits for the
+// Retry-After duration and sends it again. It stops at the first message that
+// fails, returning an error that wraps the cause and names the message's index,
+// or the context's error when ctx is done while waiting.
+func SendWebhookMessages(ctx context.Context, url string, msgs []*slack.WebhookMessage, opts ...SendOption) error {
+	cfg := sendConfig{client: http.DefaultClient, maxRetries: 3}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	for i, msg := range msgs {
+		if err := sendWebhookMessage(ctx, cfg, url, msg); err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func sendWebhookMessage(ctx context.Context, cfg sendConfig, url string, msg *slack.WebhookMessage) error {
+	for retry := 0; ; retry++ {
+		err := slack.PostWebhookCustomHTTPContext(ctx, url, cfg.client, msg)
+		var rateLimited *slack.RateLimitedError
+		if !errors.As(err, &rateLimited) || retry >= cfg.maxRetries {
+			return err
+		}
+		if err := sleepContext(ctx, rateLimited.RetryAfter); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for d, or returns the context's error if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slack-go/slack"
+)
+
+func TestConvertMarkdownTextToWebhookMessages(t *testing.T) {
+	c := NewConverter(WithBlocksPerMessage(2))
+	msgs, err := c.ConvertMarkdownTextToWebhookMessages("# Title\n\nfirst\n\n# Next\n\nsecond",
+		WebhookResponseType(slack.ResponseTypeInChannel),
+		WebhookReplaceOriginal(true),
+	)
+	if err != nil {
+		t.Fatalf("ConvertMarkdownTextToWebhookMessages() error = %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+
+	first, _ := json.Marshal(msgs[0])
+	want := `{"text":"Title\nfirst Next second","blocks":[{"type":"header","text":{"type":"plain_text","text":"Title","emoji":true}},{"type":"section","text":{"type":"mrkdwn","text":"first"}}],"response_type":"in_channel","replace_original":true,"delete_original":false}`
+	if string(first) != want {
+		t.Errorf("first message = %s, want %s", first, want)
+	}
+	if msgs[1].Text != "Next second" || msgs[1].ReplaceOriginal || msgs[1].ResponseType != slack.ResponseTypeInChannel {
+		t.Errorf("second message = %+v", msgs[1])
+	}
+
+	msgs, err = ConvertMarkdownTextToWebhookMessages("gone", WebhookDeleteOriginal(true), WebhookResponseType(slack.ResponseTypeEphemeral))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !msgs[0].DeleteOriginal || msgs[0].ResponseType != slack.ResponseTypeEphemeral {
+		t.Errorf("message = %+v", msgs[0])
+	}
+
+	if _, err := ConvertMarkdownTextToWebhookMessages(""); !errors.Is(err, ErrEmptyMessage) {
+		t.Errorf("ConvertMarkdownTextToWebhookMessages() error = %v, want ErrEmptyMessage", err)
+	}
+}
+
+// newWebhookServer starts a server that answers the requests with the given status
+// codes in turn, and OK after them, and records the texts of the posted messages.
+func newWebhookServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var texts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slack.WebhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		texts = append(texts, msg.Text)
+		if len(texts) <= len(statuses) {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(statuses[len(texts)-1])
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), texts...)
+	}
+}
+
+func TestSendWebhookMessages(t *testing.T) {
+	msgs := []*slack.WebhookMessage{{Text: "one"}, {Text: "two"}}
+
+	t.Run("retries rate limited messages", func(t *testing.T) {
+		server, texts := newWebhookServer(t, "0", http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests)
+		if err := SendWebhookMessages(context.Background(), server.URL, msgs); err != nil {
+			t.Fatalf("SendWebhookMessages() error = %v", err)
+		}
+		if got := strings.Join(texts(), " "); got != "one two two two" {
+			t.Errorf("sent %q", got)
+		}
+	})
+
+	t.Run("gives up after the maximum retries", func(t *testing.T) {
+		server, texts := newWebhookServer(t, "0", http.StatusTooManyRequests, http.StatusTooManyRequests)
+		err := SendWebhookMessages(context.Background(), server.URL, msgs, SendMaxRetries(1))
+		var rateLimited *slack.RateLimitedError
+		if !errors.As(err, &rateLimited) || !strings.HasPrefix(err.Error(), "message 0: ") {
+			t.Errorf("SendWebhookMessages() error = %v, want a RateLimitedError for message 0", err)
+		}
+		if got := strings.Join(texts(), " "); got != "one one" {
+			t.Errorf("sent %q", got)
+		}
+	})
+
+	t.Run("stops at errors", func(t *testing.T) {
+		server, texts := newWebhookServer(t, "", http.StatusOK, http.StatusNotFound)
+		err := SendWebhookMessages(context.Background(), server.URL, msgs, SendHTTPClient(server.Client()))
+		var statusErr slack.StatusCodeError
+		if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+			t.Errorf("SendWebhookMessages() error = %v, want a 404 StatusCodeError", err)
+		}
+		if got := strings.Join(texts(), " "); got != "one two" {
+			t.Errorf("sent %q", got)
+		}
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		server, _ := newWebhookServer(t, "60", http.StatusTooManyRequests)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := SendWebhookMessages(ctx, server.URL, msgs); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("SendWebhookMessages() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}